@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/sosdoc/gongram/solver"
 )
@@ -10,9 +17,59 @@ import (
 var fileName = flag.String("f", "puzzles/nonogram.json", "The name of the JSON file containing puzzle definitions.")
 var puzzleName = flag.String("p", "", "Name of the puzzle to solve. It has to be contained in the loaded file.")
 var listNames = flag.Bool("l", false, "Displays the names in the puzzle file without solving.")
+var maxTypos = flag.Int("d", 0, "Diagnoses wrong clues, relaxing up to the given number of rows and columns.")
+var allSolutions = flag.Bool("a", false, "Displays all the solutions of the puzzle.")
+var countSolutions = flag.Bool("c", false, "Counts the solutions of the puzzle without displaying them.")
+var maxSolutions = flag.Int("m", 100, "Maximum number of solutions to find with -a or -c, 0 for no limit.")
+var workers = flag.Int("w", 1, "Number of goroutines solving lines in parallel, 0 for one per CPU.")
+var searchers = flag.Int("s", 1, "Number of goroutines exploring the search tree in parallel, 0 for one per CPU.")
+var useAutomata = flag.Bool("automaton", false, "Solves lines with finite automata compiled from the clues.")
+var statsFormat = flag.String("stats", "", "Prints what the solver did after solving, either as text or json.")
+var animate = flag.Duration("animate", 0, "Animates the solving in the terminal, waiting the given time (e.g. 50ms) after each step.")
+var gridLines = flag.Bool("g", false, "Draws grid lines every 5 rows and columns when printing a board.")
+var plain = flag.Bool("plain", false, "Prints only the board, without the clues.")
+var outputFile = flag.String("o", "", "Draws the solution to the given file, the format is chosen by its extension: .svg, .png, .tex or .html")
+var traceSteps = flag.Bool("trace", false, "Draws every step of the solving with -o file.tex, highlighting the cells deduced.")
+var blank = flag.Bool("blank", false, "Draws the blank puzzle with -o, without solving it.")
+var cellSize = flag.Int("cell", 20, "Size of the cells in pixels for -o.")
+var fontFamily = flag.String("font", "sans-serif", "Font family of the clues for -o.")
+var drawMarks = flag.Bool("marks", false, "Draws a × in the marked cells with -o.")
+var noClues = flag.Bool("noclues", false, "Leaves out the clues with -o, drawing only the grid.")
+var bookFile = flag.String("book", "", "Writes all the puzzles of the loaded file to the given PDF file, with an answer key.")
+var perPage = flag.Int("perpage", 4, "Number of puzzles on each page of the -book.")
+var noAnswers = flag.Bool("noanswers", false, "Leaves out the answer key of the -book.")
+var saveFile = flag.String("save", "", "Resumes the game saved in the given file with play, saving it there when quitting.")
+var listenAddr = flag.String("addr", ":8080", "Address the HTTP server listens on with serve.")
+var requestTimeout = flag.Duration("timeout", 10*time.Second, "Time given to the solver for each request with serve, or to solve the puzzle with play.")
+var jobsDir = flag.String("jobs", "", "Queues the puzzles posted to /jobs with serve, storing the results in the given directory.")
+var jobWorkers = flag.Int("jobworkers", 0, "Number of jobs solved at the same time with -jobs, 0 for one per CPU.")
+var jobTimeout = flag.Duration("jobtimeout", time.Minute, "Time given to the solver for each job with -jobs.")
+var useSAT = flag.Bool("sat", false, "Solves the puzzle with the SAT solver instead of the tree solver.")
+var cnfFile = flag.String("cnf", "", "Writes the puzzle to the given file as a DIMACS CNF formula without solving.")
+var varMapFile = flag.String("varmap", "", "Writes what the variables of the CNF formula stand for to the given file, with -cnf.")
+var modelFile = flag.String("model", "", "Reads the solution from the output of an external SAT solver run on the -cnf formula.")
+
+// tracer records the steps of the solving for -trace.
+var tracer *solver.Tracer
+
+// animator draws the solving for -animate.
+var animator *solver.Animator
 
 func main() {
-	flag.Parse()
+	// the first argument can be a command, followed by the flags
+	command := ""
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = os.Args[1]
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
+	if command != "" && command != "play" && command != "serve" {
+		fmt.Println("Unknown command", command)
+		flag.Usage()
+		return
+	}
+
 	jsonObj, err := solver.ReadJSONPuzzleFile(*fileName)
 
 	if err != nil {
@@ -20,6 +77,21 @@ func main() {
 		return
 	}
 
+	if *bookFile != "" {
+		writeBook(jsonObj)
+		return
+	}
+
+	if command == "play" {
+		play(jsonObj)
+		return
+	}
+
+	if command == "serve" {
+		serve(jsonObj)
+		return
+	}
+
 	if *listNames || *puzzleName == "" {
 		jsonObj.ListNames()
 		return
@@ -33,7 +105,292 @@ func main() {
 	}
 
 	fmt.Println("Loaded puzzle:", puzzle.Name)
-	s := solver.NewTreeSolver(puzzle)
+
+	if *maxTypos > 0 {
+		diagnose(puzzle)
+		return
+	}
+
+	if *blank && *outputFile != "" {
+		writeOutput(puzzle, nil)
+		return
+	}
+
+	if *modelFile != "" {
+		readModel(puzzle)
+		return
+	}
+
+	if *cnfFile != "" {
+		writeCNF(puzzle)
+		return
+	}
+
+	if *useSAT {
+		s := solver.NewSATSolver(puzzle)
+		board, ok := s.SolveContext(context.Background())
+		if !ok {
+			fmt.Println("The puzzle has no solution")
+			return
+		}
+		printBoard(puzzle, board)
+		fmt.Printf("%d conflicts, %d decisions\n", s.Conflicts, s.Decisions)
+		writeOutput(puzzle, board)
+		return
+	}
+
+	if *outputFile != "" && (*allSolutions || *countSolutions) {
+		fmt.Println("-o draws a single solution, it can't be used with -a or -c")
+		return
+	}
+
+	if *searchers != 1 {
+		parallelSearch(puzzle)
+		return
+	}
+
+	if *allSolutions || *countSolutions {
+		enumerate(puzzle)
+		return
+	}
+
+	s := solver.NewTreeSolver(puzzle, treeSolverOptions()...)
 	board := s.Solve()
-	fmt.Println(board)
+	if animator == nil || animator.Err() != nil {
+		// the animation already ends with the solution, unless it stopped
+		if animator != nil {
+			fmt.Println(animator.Err())
+		}
+		printBoard(puzzle, board)
+	}
+	printStats(s)
+	writeOutput(puzzle, board)
+}
+
+// printBoard prints the board along with the clues of the puzzle, unless -plain is given.
+func printBoard(puzzle solver.Puzzle, board solver.Board) {
+	if *plain {
+		fmt.Println(board)
+		return
+	}
+	fmt.Println(solver.RenderBoard(puzzle, board, *gridLines))
+}
+
+// treeSolverOptions returns the options for the TreeSolver selected by the flags.
+func treeSolverOptions() []solver.Option {
+	options := []solver.Option{solver.WithWorkers(*workers)}
+	if *useAutomata {
+		options = append(options, solver.WithAutomatonLineSolver())
+	}
+	if *animate > 0 {
+		animator = solver.NewAnimator(os.Stdout, *animate)
+		options = append(options, solver.WithObserver(animator))
+	}
+	if *traceSteps {
+		tracer = solver.NewTracer()
+		options = append(options, solver.WithObserver(tracer))
+	}
+	return options
+}
+
+func diagnose(puzzle solver.Puzzle) {
+	d, err := solver.Diagnose(puzzle, *maxTypos)
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if len(d.Typos) == 0 {
+		fmt.Println("No wrong clues found")
+	} else {
+		fmt.Println("Likely wrong clues:")
+		for _, typo := range d.Typos {
+			fmt.Println("\t", typo)
+		}
+	}
+	printBoard(puzzle, d.Board)
+}
+
+func writeCNF(puzzle solver.Puzzle) {
+	cnf := solver.EncodeCNF(puzzle)
+
+	if err := writeFile(*cnfFile, cnf.WriteDIMACS); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Written", *cnfFile)
+
+	if *varMapFile != "" {
+		if err := writeFile(*varMapFile, cnf.WriteVarMap); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println("Written", *varMapFile)
+	}
+}
+
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeBook writes all the puzzles of the file to the -book PDF, titled after the file.
+func writeBook(jsonObj solver.JSONObject) {
+	title := strings.TrimSuffix(filepath.Base(*fileName), filepath.Ext(*fileName))
+	options := solver.BookOptions{Title: title, PerPage: *perPage, NoAnswers: *noAnswers}
+
+	err := writeFile(*bookFile, func(w io.Writer) error {
+		return solver.WriteBook(w, jsonObj.Puzzles, options)
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Wrote %d puzzles to %s\n", len(jsonObj.Puzzles), *bookFile)
+}
+
+func readModel(puzzle solver.Puzzle) {
+	f, err := os.Open(*modelFile)
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer f.Close()
+
+	board, err := solver.EncodeCNF(puzzle).ReadSolution(f)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printBoard(puzzle, board)
+	writeOutput(puzzle, board)
+}
+
+// writeOutput draws the board (or the blank puzzle if board is nil) to the file given
+// with -o, if any.
+func writeOutput(puzzle solver.Puzzle, board solver.Board) {
+	if *outputFile == "" {
+		return
+	}
+
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(*outputFile)) {
+	case ".svg":
+		options := solver.SVGOptions{CellSize: *cellSize, FontFamily: *fontFamily, Marks: *drawMarks, NoClues: *noClues}
+		write = func(w io.Writer) error {
+			return solver.WriteSVG(w, puzzle, board, options)
+		}
+	case ".png":
+		options := solver.PNGOptions{CellSize: *cellSize, Marks: *drawMarks, NoClues: *noClues}
+		write = func(w io.Writer) error {
+			return solver.WritePNG(w, puzzle, board, options)
+		}
+	case ".tex":
+		options := solver.TikZOptions{Marks: *drawMarks, NoClues: *noClues}
+		write = func(w io.Writer) error {
+			if tracer != nil {
+				return solver.WriteTikZTrace(w, puzzle, tracer.Steps, options)
+			}
+			return solver.WriteTikZ(w, puzzle, board, options)
+		}
+	case ".html":
+		write = func(w io.Writer) error {
+			return solver.WriteHTML(w, puzzle)
+		}
+	default:
+		fmt.Println("Unsupported output format", *outputFile)
+		return
+	}
+
+	if err := writeFile(*outputFile, write); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Written", *outputFile)
+}
+
+func enumerate(puzzle solver.Puzzle) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := solver.NewTreeSolver(puzzle, treeSolverOptions()...)
+	solutions := s.Solutions(ctx)
+
+	count := 0
+	for board := range solutions {
+		if count == *maxSolutions {
+			// only stopped if there's one more solution than the limit
+			fmt.Println("Stopped after", count, "solutions")
+			break
+		}
+		count++
+		if !*countSolutions {
+			fmt.Println("Solution", count)
+			printBoard(puzzle, board)
+		}
+	}
+	fmt.Println("Found", count, "solutions")
+
+	// wait for the search to stop before reading the stats
+	cancel()
+	for range solutions {
+	}
+	printStats(s)
+}
+
+func parallelSearch(puzzle solver.Puzzle) {
+	limit := 1
+	if *allSolutions || *countSolutions {
+		limit = *maxSolutions
+	}
+
+	s := solver.NewTreeSolver(puzzle, treeSolverOptions()...)
+	result := s.ParallelSearch(context.Background(), *searchers, limit)
+
+	for i, board := range result.Solutions {
+		if !*countSolutions {
+			fmt.Println("Solution", i+1)
+			printBoard(puzzle, board)
+		}
+	}
+	if *allSolutions || *countSolutions {
+		fmt.Println("Found", len(result.Solutions), "solutions")
+	}
+
+	for i, stats := range result.Workers {
+		fmt.Printf("Worker %d: %d nodes, %d steps, %d guesses, %d contradictions, %d solutions\n",
+			i, stats.Nodes, stats.Steps, stats.Guesses, stats.Contradictions, stats.Solutions)
+	}
+	printStats(s)
+	if len(result.Solutions) > 0 {
+		writeOutput(puzzle, result.Solutions[0])
+	}
+}
+
+// printStats prints the stats of the solver in the format chosen with -stats, if any.
+func printStats(s *solver.TreeSolver) {
+	switch *statsFormat {
+	case "":
+	case "text":
+		fmt.Println(s.Stats())
+	case "json":
+		data, err := json.MarshalIndent(s.Stats(), "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Println("Unknown stats format", *statsFormat, "use text or json")
+	}
 }
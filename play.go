@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sosdoc/gongram/solver"
+)
+
+// Terminal escape sequences used by the game.
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+const playHelp = "arrows/hjkl move  space/f fill  x/m mark  u undo  r redo  ? hint  c check  q quit"
+
+// play lets the user solve the -p puzzle in the terminal, or resume the game in the
+// -save file, switching the terminal to raw mode so that keys are read as they are pressed.
+func play(jsonObj solver.JSONObject) {
+	g, err := loadGame(jsonObj)
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	puzzle := g.Puzzle
+
+	restore, err := rawTerminal()
+	if err != nil {
+		fmt.Println("Can't play in this terminal:", err)
+		return
+	}
+	if *saveFile != "" {
+		defer func() {
+			if err := writeFile(*saveFile, g.Save); err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Println("Saved the game to", *saveFile)
+		}()
+	}
+	defer restore()
+
+	fmt.Print(enterScreen)
+	defer fmt.Print(leaveScreen)
+
+	in := bufio.NewReader(os.Stdin)
+	status, check := "", false
+
+	for {
+		fmt.Print(clearScreen)
+		fmt.Printf("%s - %v\r\n\r\n%s\r\n%s\r\n\r\n%s", puzzle.Name, g.Elapsed().Round(time.Second),
+			g.Render(check), status, playHelp)
+
+		key, err := readKey(in)
+		if err != nil {
+			return
+		}
+		status, check = "", false
+
+		switch key {
+		case "up", "k":
+			g.MoveCursor(-1, 0)
+		case "down", "j":
+			g.MoveCursor(1, 0)
+		case "left", "h":
+			g.MoveCursor(0, -1)
+		case "right", "l":
+			g.MoveCursor(0, 1)
+		case " ", "f":
+			g.Fill()
+		case "x", "m":
+			g.Mark()
+		case "u":
+			if !g.Undo() {
+				status = "Nothing to undo"
+			}
+		case "r":
+			if !g.Redo() {
+				status = "Nothing to redo"
+			}
+		case "?":
+			status = g.Hint()
+		case "c":
+			check = true
+			status = fmt.Sprintf("%d mistakes", g.Mistakes())
+		case "q", "\x03":
+			return
+		}
+
+		if g.Solved() {
+			fmt.Print(clearScreen)
+			fmt.Printf("%s\r\n\r\n%s\r\nSolved in %v with %d moves and %d hints! Press any key to quit.",
+				puzzle.Name, g.Render(false), g.Elapsed().Round(time.Second), len(g.Moves()), g.Hints)
+			readKey(in)
+			return
+		}
+	}
+}
+
+// loadGame resumes the game in the -save file if it exists, finding its puzzle by its
+// clues, or starts a new game of the -p puzzle. The puzzle is solved within -timeout.
+func loadGame(jsonObj solver.JSONObject) (*solver.Game, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), *requestTimeout)
+	defer cancel()
+
+	f, err := os.Open(*saveFile)
+
+	if *saveFile == "" || os.IsNotExist(err) {
+		puzzle, err := jsonObj.GetByName(*puzzleName)
+		if err != nil {
+			return nil, err
+		}
+		return solver.NewGame(ctx, puzzle)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	save, err := solver.ReadSaveFile(f)
+	if err != nil {
+		return nil, err
+	}
+	puzzle, err := jsonObj.GetByHash(save.PuzzleHash)
+	if err != nil {
+		return nil, err
+	}
+	return solver.ResumeGame(ctx, save, puzzle)
+}
+
+// readKey reads a key press, returning the names up, down, left and right for the
+// escape sequences of the arrow keys.
+func readKey(in *bufio.Reader) (string, error) {
+	b, err := in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	if b != '\x1b' || in.Buffered() < 2 {
+		return string(b), nil
+	}
+	seq := make([]byte, 2)
+	in.Read(seq)
+	switch string(seq) {
+	case "[A":
+		return "up", nil
+	case "[B":
+		return "down", nil
+	case "[C":
+		return "right", nil
+	case "[D":
+		return "left", nil
+	}
+	return "", nil
+}
+
+// rawTerminal switches the terminal to raw mode with stty, returning a function
+// restoring the previous settings.
+func rawTerminal() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sosdoc/gongram/solver"
+)
+
+// serve exposes the solver and the puzzles of the loaded file as a JSON API over HTTP,
+// until it's interrupted.
+func serve(jsonObj solver.JSONObject) {
+	options := solver.ServerOptions{Timeout: *requestTimeout}
+	if *jobsDir != "" {
+		jobs, err := solver.NewJobQueue(solver.JobOptions{Dir: *jobsDir, Workers: *jobWorkers, Timeout: *jobTimeout})
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		// stores the jobs still waiting as cancelled, once the server is shut down
+		defer jobs.Close()
+		options.Jobs = jobs
+	}
+
+	server := &http.Server{
+		Addr:              *listenAddr,
+		Handler:           solver.NewServer(jsonObj, options),
+		ReadHeaderTimeout: 10 * time.Second,
+		// leave some time to write the response after the solver times out
+		WriteTimeout: *requestTimeout + 10*time.Second,
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		// stop accepting requests and wait for the ones being served
+		ctx, cancel := context.WithTimeout(context.Background(), server.WriteTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	fmt.Printf("Serving %d puzzles on %s\n", len(jsonObj.Puzzles), *listenAddr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		fmt.Println(err)
+		return
+	}
+	<-stopped
+	fmt.Println("Server stopped")
+}
@@ -0,0 +1,127 @@
+package solver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ANSI escape sequences used by the Animator.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiLine      = "\x1b[44m"
+	ansiChanged   = "\x1b[1;33m"
+	ansiGuess     = "\x1b[41m"
+	ansiClearLine = "\x1b[K"
+)
+
+// An Animator is an Observer that draws the board on a terminal every time the solver
+// makes progress, redrawing it in place with ANSI escape sequences.
+//
+// The line being solved is highlighted, along with the cells it just changed and the
+// cells being guessed, and a status line tells what happened. It waits for the given
+// delay after each frame so that the solving can be followed. It stops drawing after
+// the first error writing a frame, which is returned by Err.
+type Animator struct {
+	w       io.Writer
+	delay   time.Duration
+	height  int
+	changed []int
+	frame   bytes.Buffer
+	err     error
+}
+
+// NewAnimator creates an Animator drawing on w, waiting delay after each frame.
+func NewAnimator(w io.Writer, delay time.Duration) *Animator {
+	return &Animator{w: w, delay: delay}
+}
+
+// Err returns the error that stopped the animation, if any.
+func (a *Animator) Err() error {
+	return a.err
+}
+
+// CellsChanged implements Observer, the cells are highlighted by the following LineSolved.
+func (a *Animator) CellsChanged(lt LineType, index int, cells []int, board Board) {
+	a.changed = cells
+}
+
+// LineSolved implements Observer
+func (a *Animator) LineSolved(lt LineType, index int, board Board) {
+	status := fmt.Sprintf("Solved %v %d, %d cells changed", lt, index, len(a.changed))
+
+	a.draw(board, status, func(r int, c int) string {
+		i, onLine := c, r == index
+		if lt == column {
+			i, onLine = r, c == index
+		}
+		if !onLine {
+			return ""
+		}
+		for _, changed := range a.changed {
+			if changed == i {
+				return ansiLine + ansiChanged
+			}
+		}
+		return ansiLine
+	})
+	a.changed = nil
+}
+
+// Guess implements Observer
+func (a *Animator) Guess(r int, c int, value Cell, depth int, board Board) {
+	status := fmt.Sprintf("Guessed %v at row %d, column %d (depth %d)", value, r, c, depth)
+	a.draw(board, status, highlightCell(r, c))
+}
+
+// Backtrack implements Observer
+func (a *Animator) Backtrack(r int, c int, depth int, board Board) {
+	status := fmt.Sprintf("Backtracked from row %d, column %d (depth %d)", r, c, depth)
+	a.draw(board, status, highlightCell(r, c))
+}
+
+// SolutionFound implements Observer
+func (a *Animator) SolutionFound(board Board) {
+	a.draw(board, "Solution found", func(r int, c int) string { return "" })
+}
+
+func highlightCell(row int, column int) func(r int, c int) string {
+	return func(r int, c int) string {
+		if r == row && c == column {
+			return ansiGuess
+		}
+		return ""
+	}
+}
+
+// draw writes a frame over the previous one, style returns the escape sequence used
+// to highlight the cell at row r and column c, if any.
+func (a *Animator) draw(board Board, status string, style func(r int, c int) string) {
+	if a.err != nil {
+		return
+	}
+	a.frame.Reset()
+	if a.height > 0 {
+		// back to the start of the previous frame
+		fmt.Fprintf(&a.frame, "\x1b[%dA\r", a.height)
+	}
+
+	for r, line := range board {
+		a.frame.WriteString("⎹ ")
+		for c, cell := range line {
+			s := style(r, c)
+			a.frame.WriteString(s + cellText(cell))
+			if s != "" {
+				a.frame.WriteString(ansiReset)
+			}
+		}
+		a.frame.WriteString(" ⎸\n")
+	}
+	a.frame.WriteString(status + ansiClearLine + "\n")
+
+	a.height = len(board) + 1
+	if _, a.err = a.w.Write(a.frame.Bytes()); a.err == nil {
+		time.Sleep(a.delay)
+	}
+}
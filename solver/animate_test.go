@@ -0,0 +1,63 @@
+package solver
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAnimator(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("edge")
+
+	var out bytes.Buffer
+	board := NewTreeSolver(puz, WithObserver(NewAnimator(&out, 0))).Solve()
+
+	// 11 rows and the status line
+	frames := strings.Split(out.String(), "\x1b[12A\r")
+	if len(frames) < 2 {
+		t.Errorf("Expected frames redrawn in place, got %d", len(frames))
+		t.FailNow()
+	}
+	for _, frame := range frames {
+		if strings.Count(frame, "\n") != 12 {
+			t.Errorf("Expected 12 lines in frame\n%q", frame)
+			t.FailNow()
+		}
+	}
+
+	// the last frame is the solution without highlights
+	last := frames[len(frames)-1]
+	if expected := board.String() + "Solution found" + ansiClearLine + "\n"; last != expected {
+		t.Errorf("Expected the last frame\n%q, got\n%q", expected, last)
+		t.FailNow()
+	}
+	if !strings.Contains(out.String(), ansiChanged) || !strings.Contains(out.String(), ansiGuess) {
+		t.Errorf("Expected changed and guessed cells to be highlighted")
+		t.FailNow()
+	}
+}
+
+// failingWriter fails every write, counting them.
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("write failed")
+}
+
+func TestAnimatorError(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("edge")
+
+	w := &failingWriter{}
+	a := NewAnimator(w, 0)
+	NewTreeSolver(puz, WithObserver(a)).Solve()
+	if a.Err() == nil || a.Err().Error() != "write failed" || w.writes != 1 {
+		t.Errorf("Expected the animation to stop at the first error, got %v after %d writes", a.Err(), w.writes)
+		t.FailNow()
+	}
+}
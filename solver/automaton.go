@@ -0,0 +1,252 @@
+package solver
+
+import (
+	"container/list"
+	"encoding/binary"
+	"sync"
+)
+
+// The symbols read by a lineAutomaton, one for each value a solved cell can take.
+const (
+	symbolSpace = iota
+	symbolFull
+	numSymbols
+)
+
+// lineAutomaton is a deterministic finite automaton accepting the lines that satisfy
+// a set of constraints, read one cell at a time.
+//
+// Clues like [3, 1, 2] describe the regular language 0* 1{3} 0+ 1 0+ 1{2} 0*, the automaton
+// has a state for each cell of each block and one for each gap between blocks, which is
+// the only state looping on spaces. An Unknown block is a single state looping on full cells.
+// Missing transitions lead to an implicit dead state.
+type lineAutomaton struct {
+	next      [][numSymbols]int
+	accepting []bool
+}
+
+// compileAutomaton builds the automaton for the given constraints.
+func compileAutomaton(constraints []int) *lineAutomaton {
+	a := &lineAutomaton{}
+
+	if len(constraints) == 0 {
+		// fully unknown line, any line will do
+		s := a.addState(true)
+		a.next[s] = [numSymbols]int{s, s}
+		return a
+	}
+
+	lastBlock := -1
+	for b, c := range constraints {
+		if c != 0 {
+			lastBlock = b
+		}
+	}
+
+	gap := a.addState(false)
+	a.next[gap][symbolSpace] = gap
+
+	for b, c := range constraints {
+		if c == 0 {
+			continue
+		}
+		last := b == lastBlock
+
+		var end int
+		if c == Unknown {
+			end = a.addState(last)
+			a.next[gap][symbolFull] = end
+			a.next[end][symbolFull] = end
+		} else {
+			end = gap
+			for i := 0; i < c; i++ {
+				s := a.addState(last && i == c-1)
+				a.next[end][symbolFull] = s
+				end = s
+			}
+		}
+
+		gap = a.addState(last)
+		a.next[end][symbolSpace] = gap
+		a.next[gap][symbolSpace] = gap
+	}
+	a.accepting[gap] = true
+	return a
+}
+
+func (a *lineAutomaton) addState(accepting bool) int {
+	a.next = append(a.next, [numSymbols]int{-1, -1})
+	a.accepting = append(a.accepting, accepting)
+	return len(a.next) - 1
+}
+
+// solve finds the cells forced by the automaton, with a forward pass computing the
+// states reachable after each prefix of the line and a backward pass computing the
+// states from which each suffix leads to an accepting state. A cell can take a value
+// if it moves a state reached from the start to a state that can reach the end.
+func (a *lineAutomaton) solve(line []Cell) (result []Cell, ok bool) {
+	n, k := len(line), len(a.next)
+
+	// forward[i*k+s] is true if state s is reachable after reading the first i cells
+	forward := make([]bool, (n+1)*k)
+	backward := make([]bool, (n+1)*k)
+	forward[0] = true
+	copy(backward[n*k:], a.accepting)
+
+	for i, cell := range line {
+		for s := 0; s < k; s++ {
+			if !forward[i*k+s] {
+				continue
+			}
+			for symbol, t := range a.next[s] {
+				if t >= 0 && allows(cell, symbol) {
+					forward[(i+1)*k+t] = true
+				}
+			}
+		}
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for s := 0; s < k; s++ {
+			for symbol, t := range a.next[s] {
+				if t >= 0 && allows(line[i], symbol) && backward[(i+1)*k+t] {
+					backward[i*k+s] = true
+					break
+				}
+			}
+		}
+	}
+
+	result = make([]Cell, n)
+	for i := range line {
+		var possible [numSymbols]bool
+		for s := 0; s < k; s++ {
+			if !forward[i*k+s] {
+				continue
+			}
+			for symbol, t := range a.next[s] {
+				if t >= 0 && allows(line[i], symbol) && backward[(i+1)*k+t] {
+					possible[symbol] = true
+				}
+			}
+		}
+
+		switch {
+		case possible[symbolSpace] && possible[symbolFull]:
+			result[i] = empty
+		case possible[symbolFull]:
+			result[i] = full
+		case possible[symbolSpace]:
+			result[i] = marked
+		default:
+			return
+		}
+	}
+	ok = true
+	return
+}
+
+// allows checks if a cell can be read as the given symbol.
+func allows(cell Cell, symbol int) bool {
+	switch cell {
+	case full:
+		return symbol == symbolFull
+	case marked:
+		return symbol == symbolSpace
+	}
+	return true
+}
+
+// automatonCache holds the automata compiled for the most recently used sets of
+// constraints, evicting the least recently used one when full. It's safe for
+// concurrent use.
+type automatonCache struct {
+	mu       sync.Mutex
+	capacity int
+	automata map[string]*list.Element
+	order    *list.List
+}
+
+type automatonCacheEntry struct {
+	key       string
+	automaton *lineAutomaton
+}
+
+// automata is shared by all the solvers, it holds enough automata for the lines of
+// many puzzles while bounding the memory used by a long running process.
+var automata = newAutomatonCache(4096)
+
+func newAutomatonCache(capacity int) *automatonCache {
+	return &automatonCache{
+		capacity: capacity,
+		automata: make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *automatonCache) get(constraints []int) *lineAutomaton {
+	buf := make([]byte, 0, 2*len(constraints)+2)
+	buf = binary.AppendUvarint(buf, uint64(len(constraints)))
+	for _, c := range constraints {
+		buf = binary.AppendVarint(buf, int64(c))
+	}
+	key := string(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, found := c.automata[key]; found {
+		c.order.MoveToFront(e)
+		return e.Value.(*automatonCacheEntry).automaton
+	}
+	a := compileAutomaton(constraints)
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.automata, oldest.Value.(*automatonCacheEntry).key)
+	}
+	c.automata[key] = c.order.PushFront(&automatonCacheEntry{key, a})
+	return a
+}
+
+// SolveLineAutomaton is a line solver that compiles the constraints into a finite
+// automaton, then fills in every cell that has the same value in all the lines accepted
+// by the automaton which are consistent with the given one.
+//
+// Like SolveLine it fails only when a contradiction is found. Unknown block lengths
+// and fully unknown lines are handled by the automaton itself. Automata are compiled
+// once for each set of constraints and shared by all the solvers, unless the blocks
+// can't fit in the line, which fails without compiling anything.
+func SolveLineAutomaton(constraints []int, line []Cell) (result []Cell, ok bool) {
+	if !blocksFit(constraints, len(line)) {
+		return nil, false
+	}
+	return automata.get(constraints).solve(line)
+}
+
+// blocksFit checks if the blocks of the constraints, separated by a single space and
+// with Unknown blocks one cell long, fit in a line of the given length.
+func blocksFit(constraints []int, length int) bool {
+	needed := -1
+	for _, c := range constraints {
+		switch {
+		case c == Unknown:
+			needed += 2
+		case c > 0:
+			needed += c + 1
+		}
+		if needed > length {
+			return false
+		}
+	}
+	return true
+}
+
+// solveAutomatonBitLine is SolveLineAutomaton for packed lines.
+func solveAutomatonBitLine(constraints []int, line bitLine) (bitLine, bool) {
+	result, ok := SolveLineAutomaton(constraints, line.cells())
+	if !ok {
+		return bitLine{}, false
+	}
+	return newBitLineFromCells(result), true
+}
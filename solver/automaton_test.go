@@ -0,0 +1,143 @@
+package solver
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSolveLineAutomaton(t *testing.T) {
+	line := []Cell{1, 0, 1, 0, 0}
+	constraints := []int{3}
+	expected := []Cell{1, 1, 1, 2, 2}
+
+	result, ok := SolveLineAutomaton(constraints, line)
+
+	if !ok || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+
+	line = []Cell{0, 0, 0, 2, 0, 0}
+	constraints = []int{Unknown, 2}
+	expected = []Cell{0, 0, 0, 2, 1, 1}
+
+	result, ok = SolveLineAutomaton(constraints, line)
+
+	if !ok || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+
+	line = []Cell{0, 1, 0, 1, 0}
+	constraints = []int{1}
+
+	if _, ok = SolveLineAutomaton(constraints, line); ok {
+		t.Errorf("Expected a contradiction for %v with %v", line, constraints)
+		t.FailNow()
+	}
+
+	if automata.get([]int{3, 1}) != automata.get([]int{3, 1}) {
+		t.Errorf("Expected the automaton to be cached")
+		t.FailNow()
+	}
+}
+
+// TestSolveLineAutomatonTooLong checks that blocks longer than the line fail without
+// compiling an automaton with a state for each of their cells.
+func TestSolveLineAutomatonTooLong(t *testing.T) {
+	if _, ok := SolveLineAutomaton([]int{math.MaxInt32}, []Cell{empty}); ok {
+		t.Errorf("Expected a block longer than the line to fail")
+		t.FailNow()
+	}
+	if _, ok := SolveLineAutomaton([]int{1, Unknown, 1}, make([]Cell, 4)); ok {
+		t.Errorf("Expected blocks and gaps longer than the line to fail")
+		t.FailNow()
+	}
+	if _, ok := SolveLineAutomaton([]int{1, Unknown, 1}, make([]Cell, 5)); !ok {
+		t.Errorf("Expected blocks and gaps as long as the line to fit")
+		t.FailNow()
+	}
+
+	p := Puzzle{Rows: [][]int{{math.MaxInt32}}, Cols: [][]int{{1}}}
+	if _, ok := NewTreeSolver(p, WithAutomatonLineSolver()).SolveContext(context.Background()); ok {
+		t.Errorf("Expected a puzzle with a block longer than the board to be unsolvable")
+		t.FailNow()
+	}
+}
+
+func TestAutomatonCacheEviction(t *testing.T) {
+	c := newAutomatonCache(2)
+	a := c.get([]int{1})
+	c.get([]int{2})
+	c.get([]int{1})
+	c.get([]int{3})
+
+	if c.order.Len() != 2 || c.get([]int{1}) != a {
+		t.Errorf("Expected the most recently used automaton to be kept")
+		t.FailNow()
+	}
+	if _, found := c.automata[string([]byte{1, 4})]; found {
+		t.Errorf("Expected the least recently used automaton to be evicted")
+		t.FailNow()
+	}
+}
+
+// TestSolveLineAutomatonRandom compares the automaton line solver with reachLine on
+// random lines, with and without unknown block lengths.
+func TestSolveLineAutomatonRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		line := make([]Cell, 1+r.Intn(40))
+		for j := range line {
+			switch r.Intn(8) {
+			case 0:
+				line[j] = full
+			case 1:
+				line[j] = marked
+			}
+		}
+
+		constraints := make([]int, 0)
+		for rest := len(line); rest > 0 && r.Intn(6) > 0; {
+			c := 1 + r.Intn(1+rest/3)
+			rest -= c + 1
+			if r.Intn(5) == 0 {
+				c = Unknown
+			}
+			constraints = append(constraints, c)
+		}
+		if len(constraints) == 0 && r.Intn(2) == 0 {
+			constraints = append(constraints, 0)
+		}
+
+		expected, expectedOk := reachLine(constraints, line)
+		result, ok := SolveLineAutomaton(constraints, line)
+
+		if ok != expectedOk || ok && !reflect.DeepEqual(result, expected) {
+			t.Errorf("%v with %v: expected %v, got %v", line, constraints, expected, result)
+			t.FailNow()
+		}
+	}
+}
+
+func TestSolveAllAutomaton(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		if puz.Name == "forever" && testing.Short() {
+			// takes a few seconds with the automaton line solver
+			continue
+		}
+		expected := NewTreeSolver(puz).Solve()
+		board := NewTreeSolver(puz, WithAutomatonLineSolver()).Solve()
+
+		if !reflect.DeepEqual(board, expected) {
+			t.Errorf("Puzzle %s: expected\n%v\ngot\n%v", puz.Name, expected, board)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package solver
+
+import "math/bits"
+
+// bitset is a fixed size set of bits packed in 64 bit words, the bits past the
+// length it was created with are always kept to zero.
+type bitset []uint64
+
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bitset) clear(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+func (b bitset) get(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+// next returns the index of the first bit set starting from i, or -1 if there are none.
+func (b bitset) next(i int) int {
+	for w := i / 64; w < len(b); w++ {
+		word := b[w]
+		if w == i/64 {
+			word &= ^uint64(0) << uint(i%64)
+		}
+		if word != 0 {
+			return w*64 + bits.TrailingZeros64(word)
+		}
+	}
+	return -1
+}
+
+func (b bitset) equal(o bitset) bool {
+	for i := range b {
+		if b[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// count returns the number of bits set.
+func (b bitset) count() (n int) {
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return
+}
+
+// setRange sets all the bits in [0, n).
+func (b bitset) setRange(n int) {
+	for i := range b {
+		switch {
+		case n >= (i+1)*64:
+			b[i] = ^uint64(0)
+		case n > i*64:
+			b[i] = ^uint64(0) >> uint((i+1)*64-n)
+		default:
+			b[i] = 0
+		}
+	}
+}
+
+// reverse reverses the order of all the bits of b, including the unused ones
+// in the last word.
+func (b bitset) reverse() {
+	for i, j := 0, len(b)-1; i <= j; i, j = i+1, j-1 {
+		b[i], b[j] = bits.Reverse64(b[j]), bits.Reverse64(b[i])
+	}
+}
+
+// The following operations store their result in the receiver, which can be one of the operands.
+
+func (b bitset) copyFrom(src bitset) {
+	copy(b, src)
+}
+
+func (b bitset) and(x bitset, y bitset) {
+	for i := range b {
+		b[i] = x[i] & y[i]
+	}
+}
+
+func (b bitset) or(x bitset, y bitset) {
+	for i := range b {
+		b[i] = x[i] | y[i]
+	}
+}
+
+func (b bitset) xor(x bitset, y bitset) {
+	for i := range b {
+		b[i] = x[i] ^ y[i]
+	}
+}
+
+// shiftUp moves all the bits of src k positions toward the higher indexes, bits
+// shifted past the last word are lost.
+func (b bitset) shiftUp(src bitset, k int) {
+	w, r := k/64, uint(k%64)
+	for i := len(b) - 1; i >= 0; i-- {
+		var word uint64
+		if i-w >= 0 && i-w < len(src) {
+			word = src[i-w] << r
+		}
+		if r > 0 && i-w-1 >= 0 && i-w-1 < len(src) {
+			word |= src[i-w-1] >> (64 - r)
+		}
+		b[i] = word
+	}
+}
+
+// shiftDown moves all the bits of src k positions toward the lower indexes.
+func (b bitset) shiftDown(src bitset, k int) {
+	w, r := k/64, uint(k%64)
+	for i := range b {
+		var word uint64
+		if i+w < len(src) {
+			word = src[i+w] >> r
+			if r > 0 && i+w+1 < len(src) {
+				word |= src[i+w+1] << (64 - r)
+			}
+		}
+		b[i] = word
+	}
+}
+
+// bitLine is a packed line of n cells: bit i of full is set if cell i can be full,
+// bit i of empty is set if the cell can be empty (marked).
+// A cell that has not been solved yet has both bits set, a cell with none of them is
+// a contradiction.
+type bitLine struct {
+	n     int
+	full  bitset
+	empty bitset
+}
+
+func newBitLine(n int) bitLine {
+	l := bitLine{n: n, full: newBitset(n), empty: newBitset(n)}
+	l.full.setRange(n)
+	l.empty.setRange(n)
+	return l
+}
+
+// newBitLineFromCells packs a line of Cells.
+func newBitLineFromCells(line []Cell) bitLine {
+	l := newBitLine(len(line))
+	for i, cell := range line {
+		l.set(i, cell)
+	}
+	return l
+}
+
+func (l bitLine) get(i int) Cell {
+	f, e := l.full.get(i), l.empty.get(i)
+	switch {
+	case f && !e:
+		return full
+	case e && !f:
+		return marked
+	}
+	return empty
+}
+
+func (l bitLine) set(i int, cell Cell) {
+	switch cell {
+	case full:
+		l.full.set(i)
+		l.empty.clear(i)
+	case marked:
+		l.full.clear(i)
+		l.empty.set(i)
+	default:
+		l.full.set(i)
+		l.empty.set(i)
+	}
+}
+
+func (l bitLine) clone() bitLine {
+	c := bitLine{n: l.n, full: newBitset(l.n), empty: newBitset(l.n)}
+	c.full.copyFrom(l.full)
+	c.empty.copyFrom(l.empty)
+	return c
+}
+
+func (l bitLine) equal(o bitLine) bool {
+	return l.full.equal(o.full) && l.empty.equal(o.empty)
+}
+
+// changes returns the set of cells that differ between the two lines.
+func (l bitLine) changes(o bitLine) bitset {
+	changed, tmp := newBitset(l.n), newBitset(l.n)
+	changed.xor(l.full, o.full)
+	tmp.xor(l.empty, o.empty)
+	changed.or(changed, tmp)
+	return changed
+}
+
+// cells unpacks the line in a slice of Cells.
+func (l bitLine) cells() []Cell {
+	line := make([]Cell, l.n)
+	for i := range line {
+		line[i] = l.get(i)
+	}
+	return line
+}
+
+// bitBoard is the packed counterpart of a Board: every row and column is stored as a
+// bitLine, so that lines can be read in both directions without copying cells around.
+type bitBoard struct {
+	rows []bitLine
+	cols []bitLine
+}
+
+func newBitBoard(rows int, columns int) (b bitBoard) {
+	b.rows = make([]bitLine, rows)
+	for i := range b.rows {
+		b.rows[i] = newBitLine(columns)
+	}
+	b.cols = make([]bitLine, columns)
+	for i := range b.cols {
+		b.cols[i] = newBitLine(rows)
+	}
+	return
+}
+
+func (b bitBoard) get(r int, c int) Cell {
+	return b.rows[r].get(c)
+}
+
+func (b bitBoard) set(r int, c int, cell Cell) {
+	b.rows[r].set(c, cell)
+	b.cols[c].set(r, cell)
+}
+
+// line returns the stored row or column, it must not be modified directly.
+func (b bitBoard) line(lt LineType, index int) bitLine {
+	if lt == row {
+		return b.rows[index]
+	}
+	return b.cols[index]
+}
+
+// setLine replaces a row or column with the given line, updating the crossing lines
+// at the given changed cells.
+func (b bitBoard) setLine(lt LineType, index int, l bitLine, changed bitset) {
+	lines, crossing := b.rows, b.cols
+	if lt == column {
+		lines, crossing = b.cols, b.rows
+	}
+
+	lines[index].full.copyFrom(l.full)
+	lines[index].empty.copyFrom(l.empty)
+
+	for i := changed.next(0); i >= 0; i = changed.next(i + 1) {
+		crossing[i].set(index, l.get(i))
+	}
+}
+
+func (b bitBoard) emptyCells() (count int) {
+	for _, l := range b.rows {
+		for i := range l.full {
+			count += bits.OnesCount64(l.full[i] & l.empty[i])
+		}
+	}
+	return
+}
+
+func (b bitBoard) clone() bitBoard {
+	c := bitBoard{rows: make([]bitLine, len(b.rows)), cols: make([]bitLine, len(b.cols))}
+
+	// the bitsets of all lines are sliced from a single allocation
+	size := 0
+	for _, l := range b.rows {
+		size += 2 * len(l.full)
+	}
+	for _, l := range b.cols {
+		size += 2 * len(l.full)
+	}
+	slab := make([]uint64, size)
+
+	cloneLines := func(dst []bitLine, src []bitLine) {
+		for i, l := range src {
+			words := len(l.full)
+			dst[i] = bitLine{n: l.n, full: slab[:words:words], empty: slab[words : 2*words : 2*words]}
+			copy(dst[i].full, l.full)
+			copy(dst[i].empty, l.empty)
+			slab = slab[2*words:]
+		}
+	}
+	cloneLines(c.rows, b.rows)
+	cloneLines(c.cols, b.cols)
+	return c
+}
+
+// toBoard unpacks the bitBoard in a Board.
+func (b bitBoard) toBoard() Board {
+	board := make([][]Cell, len(b.rows))
+	for i, l := range b.rows {
+		board[i] = l.cells()
+	}
+	return board
+}
@@ -0,0 +1,154 @@
+package solver
+
+import "math/bits"
+
+// solveBitLine is the line solver working on packed lines, it finds all the cells
+// that have the same value in every placement of the blocks consistent with the line.
+//
+// Positions are shifted by one so that the line is surrounded by two empty sentinel
+// cells, then for each block b the set of positions where it can start is computed with
+// bitwise operations on whole words:
+//   - going forward, the positions reachable after placing the blocks before b,
+//   - going backward, the positions from which the blocks after b can still be placed.
+//
+// A cell can be full if a block can cover it in a start position reachable both ways,
+// it can be empty if it can be the gap between two blocks (or the line borders) that
+// are reachable both ways.
+// Constraints with unknown blocks are handed over to reachLine.
+func solveBitLine(constraints []int, line bitLine) (result bitLine, ok bool) {
+	if hasUnknown(constraints) {
+		var cells []Cell
+		cells, ok = reachLine(constraints, line.cells())
+		result = newBitLineFromCells(cells)
+		return
+	}
+	if len(constraints) == 1 && constraints[0] == 0 {
+		constraints = constraints[:0]
+	}
+
+	n, m := line.n+2, len(constraints)
+	words := (n + 63) / 64
+
+	// all the bitsets are sliced from a single allocation
+	slab := make([]uint64, words*(2*m+9))
+	alloc := func() bitset {
+		b := bitset(slab[:words:words])
+		slab = slab[words:]
+		return b
+	}
+
+	canFull, canEmpty := alloc(), alloc()
+	canFull.shiftUp(line.full, 1)
+	canEmpty.shiftUp(line.empty, 1)
+	canEmpty.set(0)
+	canEmpty.set(n - 1)
+
+	// starts[b] holds the positions where block b fits, followed by an empty cell
+	starts := make([]bitset, m)
+	tmp := alloc()
+	for b, c := range constraints {
+		starts[b] = alloc()
+		runOfLength(starts[b], canFull, c, tmp)
+		tmp.shiftDown(canEmpty, c)
+		starts[b].and(starts[b], tmp)
+	}
+
+	// forward[b] holds the empty positions reachable after placing the first b blocks
+	forward := make([]bitset, m+1)
+	forward[0] = alloc()
+	forward[0].set(0)
+	fill(forward[0], canEmpty, true)
+
+	for b, c := range constraints {
+		forward[b+1] = alloc()
+		forward[b+1].shiftUp(forward[b], 1)
+		forward[b+1].and(forward[b+1], starts[b])
+		forward[b+1].shiftUp(forward[b+1], c)
+		fill(forward[b+1], canEmpty, true)
+	}
+
+	if !forward[m].get(n - 1) {
+		return
+	}
+	ok = true
+
+	// backward holds the empty positions from which the blocks from b onwards can be placed
+	backward, valid, resultFull, resultEmpty := alloc(), alloc(), alloc(), alloc()
+	backward.set(n - 1)
+	fill(backward, canEmpty, false)
+	resultEmpty.and(forward[m], backward)
+
+	for b := m - 1; b >= 0; b-- {
+		c := constraints[b]
+
+		valid.shiftDown(backward, c)
+		valid.and(valid, starts[b])
+		backward.shiftDown(valid, 1)
+		backward.and(backward, canEmpty)
+		fill(backward, canEmpty, false)
+
+		tmp.and(forward[b], backward)
+		resultEmpty.or(resultEmpty, tmp)
+
+		tmp.shiftUp(forward[b], 1)
+		valid.and(valid, tmp)
+		cover(valid, c, tmp)
+		resultFull.or(resultFull, valid)
+	}
+
+	result = bitLine{n: line.n, full: newBitset(line.n), empty: newBitset(line.n)}
+	result.full.shiftDown(resultFull, 1)
+	result.empty.shiftDown(resultEmpty, 1)
+	result.full.and(result.full, line.full)
+	result.empty.and(result.empty, line.empty)
+	return
+}
+
+// runOfLength sets dst to the positions that start a run of at least length bits set in src.
+func runOfLength(dst bitset, src bitset, length int, tmp bitset) {
+	dst.copyFrom(src)
+	for have := 1; have < length; {
+		step := have
+		if step > length-have {
+			step = length - have
+		}
+		tmp.shiftDown(dst, step)
+		dst.and(dst, tmp)
+		have += step
+	}
+}
+
+// cover extends every bit set in b to the following length-1 positions.
+func cover(b bitset, length int, tmp bitset) {
+	for have := 1; have < length; {
+		step := have
+		if step > length-have {
+			step = length - have
+		}
+		tmp.shiftUp(b, step)
+		b.or(b, tmp)
+		have += step
+	}
+}
+
+// fill extends the bits set in b through the runs of bits set in mask, upward or
+// downward. The bits of b must be a subset of mask.
+//
+// Filling upward is done by adding b to mask: the carry started by a bit of b runs
+// through the bits of mask that follow it, flipping them until the end of the run.
+// Filling downward does the same on the reversed bitsets.
+func fill(b bitset, mask bitset, up bool) {
+	if !up {
+		b.reverse()
+		mask.reverse()
+		defer b.reverse()
+		defer mask.reverse()
+	}
+
+	var carry uint64
+	for i := range b {
+		var sum uint64
+		sum, carry = bits.Add64(mask[i], b[i], carry)
+		b[i] = (sum ^ mask[i] | b[i]) & mask[i]
+	}
+}
@@ -0,0 +1,95 @@
+package solver
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSolveBitLine(t *testing.T) {
+	line := []Cell{0, 0, 0, 0, 0}
+	constraints := []int{3}
+	expected := []Cell{0, 0, 1, 0, 0}
+
+	result, ok := solveBitLine(constraints, newBitLineFromCells(line))
+
+	if !ok || !reflect.DeepEqual(result.cells(), expected) {
+		t.Errorf("Expected %v, got %v", expected, result.cells())
+		t.FailNow()
+	}
+
+	line = []Cell{1, 0, 1, 0, 0}
+	constraints = []int{3}
+	expected = []Cell{1, 1, 1, 2, 2}
+
+	result, ok = solveBitLine(constraints, newBitLineFromCells(line))
+
+	if !ok || !reflect.DeepEqual(result.cells(), expected) {
+		t.Errorf("Expected %v, got %v", expected, result.cells())
+		t.FailNow()
+	}
+
+	line = []Cell{0, 0, 2, 0, 0}
+	constraints = []int{0}
+	expected = []Cell{2, 2, 2, 2, 2}
+
+	result, ok = solveBitLine(constraints, newBitLineFromCells(line))
+
+	if !ok || !reflect.DeepEqual(result.cells(), expected) {
+		t.Errorf("Expected %v, got %v", expected, result.cells())
+		t.FailNow()
+	}
+
+	line = []Cell{0, 1, 0, 1, 0}
+	constraints = []int{1}
+
+	if _, ok = solveBitLine(constraints, newBitLineFromCells(line)); ok {
+		t.Errorf("Expected a contradiction for %v with %v", line, constraints)
+		t.FailNow()
+	}
+}
+
+// TestSolveBitLineRandom compares the bitwise line solver with reachLine on random
+// lines, including lines longer than a single word.
+func TestSolveBitLineRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		line := make([]Cell, 1+r.Intn(150))
+		for j := range line {
+			switch r.Intn(10) {
+			case 0:
+				line[j] = full
+			case 1:
+				line[j] = marked
+			}
+		}
+
+		constraints := make([]int, 0)
+		for rest := len(line); rest > 0 && r.Intn(8) > 0; {
+			c := 1 + r.Intn(1+rest/3)
+			constraints = append(constraints, c)
+			rest -= c + 1
+		}
+		if len(constraints) == 0 {
+			constraints = append(constraints, 0)
+		}
+
+		expected, expectedOk := reachLine(constraints, line)
+		result, ok := solveBitLine(constraints, newBitLineFromCells(line))
+
+		if ok != expectedOk || ok && !reflect.DeepEqual(result.cells(), expected) {
+			t.Errorf("%v with %v: expected %v, got %v", line, constraints, expected, result.cells())
+			t.FailNow()
+		}
+	}
+}
+
+func BenchmarkSolveBitLine(b *testing.B) {
+	line := newBitLineFromCells([]Cell{0, 1, 0, 0, 1, 0, 0, 2, 0, 0, 0, 0, 0, 0, 1, 0})
+	constraints := []int{3, 3, 1, 4, 2}
+
+	for i := 0; i < b.N; i++ {
+		solveBitLine(constraints, line)
+	}
+}
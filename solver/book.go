@@ -0,0 +1,143 @@
+package solver
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// BookOptions configures the puzzle books written by WriteBook.
+type BookOptions struct {
+	// Title is printed at the top of every page
+	Title string
+	// PerPage is the number of puzzles on each page, 4 if zero or less
+	PerPage int
+	// NoAnswers leaves out the answer key
+	NoAnswers bool
+}
+
+// The margins of the pages of a book and the space taken by the title of each page
+// and of each puzzle, in points.
+const (
+	bookMargin      = 36
+	bookHeader      = 28
+	bookPuzzleTitle = 18
+	bookMaxCell     = 18
+)
+
+// WriteBook writes a printable PDF book with the puzzles laid out in a grid, several on
+// each page, followed by an answer key with their solutions found by a TreeSolver.
+func WriteBook(w io.Writer, puzzles []Puzzle, options BookOptions) error {
+	perPage := options.PerPage
+	if perPage <= 0 {
+		perPage = 4
+	}
+	doc := newPDFDocument()
+
+	writeBookPages(doc, puzzles, nil, options.Title, perPage)
+
+	if !options.NoAnswers {
+		solutions := make([]Board, len(puzzles))
+		for i, p := range puzzles {
+			t := NewTreeSolver(p)
+			if t.solve() {
+				solutions[i] = t.board.toBoard()
+			}
+		}
+		writeBookPages(doc, puzzles, solutions, options.Title+" - Answers", perPage)
+	}
+	return doc.write(w)
+}
+
+// writeBookPages adds the pages with the puzzles, or with their solutions if solutions
+// is not nil, numbering the puzzles from 1.
+func writeBookPages(doc *pdfDocument, puzzles []Puzzle, solutions []Board, title string, perPage int) {
+	cols := int(math.Ceil(math.Sqrt(float64(perPage))))
+	rows := (perPage + cols - 1) / cols
+	slotW := float64(pdfPageWidth-2*bookMargin) / float64(cols)
+	slotH := float64(pdfPageHeight-2*bookMargin-bookHeader) / float64(rows)
+
+	for first := 0; first < len(puzzles); first += perPage {
+		page := &pdfCanvas{}
+		page.text(bookMargin, bookMargin+14, 16, true, false, title)
+		page.text(pdfPageWidth/2, pdfPageHeight-bookMargin/2, 9, false, true, fmt.Sprint(len(doc.pages)+1))
+
+		for i := first; i < first+perPage && i < len(puzzles); i++ {
+			slot := i - first
+			x := bookMargin + float64(slot%cols)*slotW
+			y := bookMargin + bookHeader + float64(slot/cols)*slotH
+
+			name := fmt.Sprintf("%d. %s", i+1, puzzles[i].Name)
+			page.text(x, y+12, 11, true, false, name)
+
+			if solutions == nil {
+				drawBookPuzzle(page, puzzles[i], nil, true, x, y+bookPuzzleTitle, slotW-12, slotH-bookPuzzleTitle-12)
+			} else if solutions[i] == nil {
+				page.text(x, y+bookPuzzleTitle+12, 10, false, false, "No solution")
+			} else {
+				drawBookPuzzle(page, puzzles[i], solutions[i], false, x, y+bookPuzzleTitle, slotW-12, slotH-bookPuzzleTitle-12)
+			}
+		}
+		doc.addPage(page)
+	}
+}
+
+// drawBookPuzzle draws the puzzle in the given box, as large as it fits up to the
+// maximum cell size, with its clues if requested and the full cells of the board.
+func drawBookPuzzle(page *pdfCanvas, p Puzzle, board Board, clues bool, x float64, y float64, w float64, h float64) {
+	rows, cols := len(p.Rows), len(p.Cols)
+	left, top := 0, 0
+	if clues {
+		for _, constraints := range p.Rows {
+			left = max(left, len(clueStrings(constraints)))
+		}
+		for _, constraints := range p.Cols {
+			top = max(top, len(clueStrings(constraints)))
+		}
+	}
+
+	cell := math.Min(w/float64(left+cols), h/float64(top+rows))
+	cell = math.Min(cell, bookMaxCell)
+	x0, y0 := x+float64(left)*cell, y+float64(top)*cell
+	fontSize := cell * 0.6
+
+	if clues {
+		for r, constraints := range p.Rows {
+			texts := clueStrings(constraints)
+			for i, text := range texts {
+				cx := x0 - (float64(len(texts)-i)-0.5)*cell
+				page.text(cx, y0+(float64(r)+0.7)*cell, fontSize, false, true, text)
+			}
+		}
+		for c, constraints := range p.Cols {
+			texts := clueStrings(constraints)
+			for i, text := range texts {
+				cy := y0 - (float64(len(texts)-i)-0.7)*cell
+				page.text(x0+(float64(c)+0.5)*cell, cy, fontSize, false, true, text)
+			}
+		}
+	}
+
+	for r := range board {
+		for c, value := range board[r] {
+			if value == full {
+				page.fillRect(x0+float64(c)*cell, y0+float64(r)*cell, cell, cell, 0)
+			}
+		}
+	}
+
+	for r := 0; r <= rows; r++ {
+		width := 0.3
+		if r%5 == 0 || r == rows {
+			width = 1.2
+		}
+		page.line(x0, y0+float64(r)*cell, x0+float64(cols)*cell, y0+float64(r)*cell, width)
+	}
+	for c := 0; c <= cols; c++ {
+		width := 0.3
+		if c%5 == 0 || c == cols {
+			width = 1.2
+		}
+		page.line(x0+float64(c)*cell, y0, x0+float64(c)*cell, y0+float64(rows)*cell, width)
+	}
+}
@@ -0,0 +1,68 @@
+package solver
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestWriteBook(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puzzles := append(inputFile.Puzzles, Puzzle{Name: "broken", Rows: [][]int{{1}}, Cols: [][]int{{0}}})
+
+	var buf bytes.Buffer
+	if err := WriteBook(&buf, puzzles, BookOptions{Title: "Test (book)", PerPage: 4}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	data := buf.Bytes()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Errorf("Missing PDF header or trailer")
+		t.FailNow()
+	}
+
+	// the puzzles and the answer key start on a new page each
+	pages := 2 * ((len(puzzles) + 3) / 4)
+	if !bytes.Contains(data, []byte(fmt.Sprintf("/Count %d >>", pages))) {
+		t.Errorf("Expected %d pages", pages)
+		t.FailNow()
+	}
+
+	// every entry of the cross-reference table points at its object
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data, -1)
+	if len(xref) != 4+2*pages {
+		t.Errorf("Expected %d objects, got %d", 4+2*pages, len(xref))
+		t.FailNow()
+	}
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("Wrong offset for object %d", i+1)
+			t.FailNow()
+		}
+	}
+}
+
+func TestWriteBookNoAnswers(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	var buf bytes.Buffer
+	if err := WriteBook(&buf, inputFile.Puzzles[:3], BookOptions{PerPage: 1, NoAnswers: true}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("/Count 3 >>")) {
+		t.Errorf("Expected a page for each puzzle")
+		t.FailNow()
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	if s := pdfString(`a(b)\ é ✓`); s != `a\(b\)\\ \351 ?` {
+		t.Errorf("Unexpected escaping %s", s)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,109 @@
+package solver
+
+import (
+	"container/list"
+	"encoding/binary"
+	"sync"
+)
+
+// LineCache is a bounded cache of line solver results, it can be shared by several
+// solvers and it's safe for concurrent use.
+//
+// Entries are keyed by a compact encoding of the constraints and of the packed line,
+// when the cache is full the least recently used entry is evicted.
+type LineCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	stats    CacheStats
+}
+
+// CacheStats counts the lookups made on a LineCache.
+type CacheStats struct {
+	Hits   int
+	Misses int
+	Size   int
+}
+
+type lineCacheEntry struct {
+	key    string
+	result bitLine
+	ok     bool
+}
+
+// NewLineCache creates a cache holding at most capacity line results.
+func NewLineCache(capacity int) *LineCache {
+	return &LineCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Stats returns the number of hits and misses since the cache was created, along
+// with the number of entries currently stored.
+func (c *LineCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+// solve returns the result of the line solver for the given constraints and line, using
+// the cached one if available, in which case hit is true. The returned line is shared
+// and must not be modified.
+//
+// Since the line solvers are complete they all give the same result, so the cache can be
+// shared by solvers using different ones.
+func (c *LineCache) solve(constraints []int, line bitLine, solveLine func([]int, bitLine) (bitLine, bool)) (result bitLine, ok bool, hit bool) {
+	key := lineCacheKey(constraints, line)
+
+	c.mu.Lock()
+	if e, found := c.entries[key]; found {
+		c.order.MoveToFront(e)
+		c.stats.Hits++
+		entry := e.Value.(*lineCacheEntry)
+		c.mu.Unlock()
+		return entry.result, entry.ok, true
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// the line is solved outside of the lock, the same line may be solved by
+	// more than one goroutine at once but the result is always the same
+	result, ok = solveLine(constraints, line)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, found := c.entries[key]; found || c.capacity <= 0 {
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lineCacheEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&lineCacheEntry{key, result, ok})
+	return
+}
+
+// lineCacheKey encodes the constraints as varints, followed by the length and the
+// words of the line.
+func lineCacheKey(constraints []int, line bitLine) string {
+	buf := make([]byte, 0, 2*len(constraints)+4+16*len(line.full))
+
+	buf = binary.AppendUvarint(buf, uint64(len(constraints)))
+	for _, c := range constraints {
+		buf = binary.AppendVarint(buf, int64(c))
+	}
+	buf = binary.AppendUvarint(buf, uint64(line.n))
+	for i := range line.full {
+		buf = binary.LittleEndian.AppendUint64(buf, line.full[i])
+		buf = binary.LittleEndian.AppendUint64(buf, line.empty[i])
+	}
+	return string(buf)
+}
@@ -0,0 +1,80 @@
+package solver
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLineCache(t *testing.T) {
+	cache := NewLineCache(2)
+	line := newBitLineFromCells([]Cell{1, 0, 1, 0, 0})
+	expected := []Cell{1, 1, 1, 2, 2}
+
+	for i := 0; i < 3; i++ {
+		result, ok, hit := cache.solve([]int{3}, line, solveBitLine)
+		if !ok || !reflect.DeepEqual(result.cells(), expected) {
+			t.Errorf("Expected %v, got %v", expected, result.cells())
+			t.FailNow()
+		}
+		if hit != (i > 0) {
+			t.Errorf("Expected a hit only after the first lookup, got %v at %d", hit, i)
+			t.FailNow()
+		}
+	}
+
+	cache.solve([]int{1, 1}, line, solveBitLine)
+	cache.solve([]int{1, 1, 1}, line, solveBitLine)
+
+	expectedStats := CacheStats{Hits: 2, Misses: 3, Size: 2}
+	if stats := cache.Stats(); stats != expectedStats {
+		t.Errorf("Expected %+v, got %+v", expectedStats, stats)
+		t.FailNow()
+	}
+
+	// the first entry was the least recently used one and has been evicted
+	cache.solve([]int{3}, line, solveBitLine)
+	if stats := cache.Stats(); stats.Misses != 4 {
+		t.Errorf("Expected 4 misses, got %+v", stats)
+		t.FailNow()
+	}
+}
+
+func TestLineCacheKey(t *testing.T) {
+	line := newBitLineFromCells([]Cell{0, 0, 0, 0})
+
+	if lineCacheKey([]int{1, 2}, line) == lineCacheKey([]int{2, 1}, line) {
+		t.Errorf("Expected different keys for different constraints")
+	}
+	if lineCacheKey([]int{1}, line) == lineCacheKey([]int{1}, newBitLineFromCells([]Cell{0, 0, 0, 0, 0})) {
+		t.Errorf("Expected different keys for different line lengths")
+	}
+	if lineCacheKey([]int{Unknown}, line) == lineCacheKey([]int{1}, line) {
+		t.Errorf("Expected different keys for unknown blocks")
+	}
+}
+
+func TestSolveWithCache(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	cache := NewLineCache(1000)
+
+	var wg sync.WaitGroup
+	for _, puz := range inputFile.Puzzles[:5] {
+		wg.Add(1)
+		go func(puz Puzzle) {
+			defer wg.Done()
+
+			expected := NewTreeSolver(puz).Solve()
+			board := NewTreeSolver(puz, WithLineCache(cache)).Solve()
+
+			if !reflect.DeepEqual(board, expected) {
+				t.Errorf("Puzzle %s: expected\n%v, got\n%v", puz.Name, expected, board)
+			}
+		}(puz)
+	}
+	wg.Wait()
+
+	if stats := cache.Stats(); stats.Misses == 0 || stats.Size > 1000 {
+		t.Errorf("Unexpected cache stats %+v", stats)
+	}
+}
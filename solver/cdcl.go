@@ -0,0 +1,471 @@
+package solver
+
+import "context"
+
+// literal is the internal representation of a DIMACS literal: 2*v for variable v
+// and 2*v+1 for its negation.
+type literal int
+
+func newLiteral(dimacs int) literal {
+	if dimacs < 0 {
+		return literal(-2*dimacs + 1)
+	}
+	return literal(2 * dimacs)
+}
+
+func (l literal) variable() int {
+	return int(l >> 1)
+}
+
+func (l literal) not() literal {
+	return l ^ 1
+}
+
+// lbool is the value of a variable or literal during the search.
+type lbool int8
+
+const (
+	undef lbool = iota
+	lTrue
+	lFalse
+)
+
+type satClause struct {
+	lits []literal
+}
+
+// cdclSolver is a conflict driven clause learning SAT solver in the style of MiniSat:
+// two watched literals for unit propagation, first UIP conflict analysis with
+// non-chronological backjumping, VSIDS variable ordering, phase saving and Luby restarts.
+type cdclSolver struct {
+	numVars  int
+	watches  [][]*satClause
+	assigns  []lbool
+	level    []int
+	reason   []*satClause
+	trail    []literal
+	trailLim []int
+	qhead    int
+	polarity []bool
+	seen     []bool
+	order    *varHeap
+	varInc   float64
+	unsat    bool
+
+	// Conflicts counts the conflicts found during the search
+	Conflicts int
+	// Decisions counts the variables assigned by guessing
+	Decisions int
+}
+
+func newCDCLSolver(cnf *CNF) *cdclSolver {
+	n := cnf.NumVars + 1
+	s := &cdclSolver{
+		numVars:  cnf.NumVars,
+		watches:  make([][]*satClause, 2*n),
+		assigns:  make([]lbool, n),
+		level:    make([]int, n),
+		reason:   make([]*satClause, n),
+		polarity: make([]bool, n),
+		seen:     make([]bool, n),
+		varInc:   1,
+	}
+	s.order = newVarHeap(n)
+	for v := 1; v < n; v++ {
+		s.order.push(v)
+	}
+
+	for _, clause := range cnf.Clauses {
+		lits := make([]literal, len(clause))
+		for i, l := range clause {
+			lits[i] = newLiteral(l)
+		}
+		s.addClause(lits)
+	}
+	return s
+}
+
+func (s *cdclSolver) value(l literal) lbool {
+	v := s.assigns[l.variable()]
+	if v == undef || l&1 == 0 {
+		return v
+	}
+	if v == lTrue {
+		return lFalse
+	}
+	return lTrue
+}
+
+func (s *cdclSolver) decisionLevel() int {
+	return len(s.trailLim)
+}
+
+// addClause adds a clause of the formula before the search starts.
+func (s *cdclSolver) addClause(lits []literal) {
+	if s.unsat {
+		return
+	}
+
+	// drop duplicated and false literals, skip satisfied clauses
+	kept := lits[:0]
+	for _, l := range lits {
+		switch s.value(l) {
+		case lTrue:
+			return
+		case lFalse:
+			continue
+		}
+		duplicate := false
+		for _, k := range kept {
+			if k == l {
+				duplicate = true
+			} else if k == l.not() {
+				return
+			}
+		}
+		if !duplicate {
+			kept = append(kept, l)
+		}
+	}
+
+	switch len(kept) {
+	case 0:
+		s.unsat = true
+	case 1:
+		s.enqueue(kept[0], nil)
+		if s.propagate() != nil {
+			s.unsat = true
+		}
+	default:
+		s.attach(&satClause{kept})
+	}
+}
+
+func (s *cdclSolver) attach(c *satClause) {
+	s.watches[c.lits[0]] = append(s.watches[c.lits[0]], c)
+	s.watches[c.lits[1]] = append(s.watches[c.lits[1]], c)
+}
+
+func (s *cdclSolver) enqueue(l literal, from *satClause) {
+	v := l.variable()
+	if l&1 == 0 {
+		s.assigns[v] = lTrue
+	} else {
+		s.assigns[v] = lFalse
+	}
+	s.level[v] = s.decisionLevel()
+	s.reason[v] = from
+	s.trail = append(s.trail, l)
+}
+
+// propagate assigns all the literals implied by unit clauses, returning the clause
+// that became false if a conflict is found.
+func (s *cdclSolver) propagate() *satClause {
+	for s.qhead < len(s.trail) {
+		falseLit := s.trail[s.qhead].not()
+		s.qhead++
+
+		ws := s.watches[falseLit]
+		i, j := 0, 0
+		for i < len(ws) {
+			c := ws[i]
+			i++
+
+			// keep the false literal in the second position
+			if c.lits[0] == falseLit {
+				c.lits[0], c.lits[1] = c.lits[1], c.lits[0]
+			}
+			if s.value(c.lits[0]) == lTrue {
+				ws[j] = c
+				j++
+				continue
+			}
+
+			// look for a new literal to watch
+			moved := false
+			for k := 2; k < len(c.lits); k++ {
+				if s.value(c.lits[k]) != lFalse {
+					c.lits[1], c.lits[k] = c.lits[k], c.lits[1]
+					s.watches[c.lits[1]] = append(s.watches[c.lits[1]], c)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			ws[j] = c
+			j++
+			if s.value(c.lits[0]) == lFalse {
+				// conflict, keep the remaining watches
+				j += copy(ws[j:], ws[i:])
+				s.watches[falseLit] = ws[:j]
+				s.qhead = len(s.trail)
+				return c
+			}
+			s.enqueue(c.lits[0], c)
+		}
+		s.watches[falseLit] = ws[:j]
+	}
+	return nil
+}
+
+// analyze derives a learnt clause from a conflict, cutting the implication graph at the
+// first unique implication point. It returns the clause, whose first literal is the one
+// to assert, and the level to backjump to.
+func (s *cdclSolver) analyze(conflict *satClause) (learnt []literal, backjump int) {
+	learnt = append(learnt, 0)
+	pathCount := 0
+	p := literal(-1)
+	index := len(s.trail) - 1
+	c := conflict
+
+	for {
+		start := 0
+		if p != -1 {
+			start = 1
+		}
+		for _, q := range c.lits[start:] {
+			v := q.variable()
+			if s.seen[v] || s.level[v] == 0 {
+				continue
+			}
+			s.bumpVar(v)
+			s.seen[v] = true
+			if s.level[v] >= s.decisionLevel() {
+				pathCount++
+			} else {
+				learnt = append(learnt, q)
+			}
+		}
+
+		// the next literal of the current level on the trail
+		for !s.seen[s.trail[index].variable()] {
+			index--
+		}
+		p = s.trail[index]
+		index--
+		c = s.reason[p.variable()]
+		s.seen[p.variable()] = false
+		pathCount--
+
+		if pathCount == 0 {
+			break
+		}
+	}
+	learnt[0] = p.not()
+
+	for _, l := range learnt[1:] {
+		s.seen[l.variable()] = false
+		if lv := s.level[l.variable()]; lv > backjump {
+			backjump = lv
+		}
+	}
+	// watch a literal of the backjump level as the second one
+	for i := 2; i < len(learnt); i++ {
+		if s.level[learnt[i].variable()] > s.level[learnt[1].variable()] {
+			learnt[1], learnt[i] = learnt[i], learnt[1]
+		}
+	}
+	return
+}
+
+// cancelUntil undoes all the assignments made after the given decision level.
+func (s *cdclSolver) cancelUntil(level int) {
+	if s.decisionLevel() <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trailLim[level]; i-- {
+		v := s.trail[i].variable()
+		s.polarity[v] = s.assigns[v] == lTrue
+		s.assigns[v] = undef
+		s.reason[v] = nil
+		if !s.order.contains(v) {
+			s.order.push(v)
+		}
+	}
+	s.trail = s.trail[:s.trailLim[level]]
+	s.trailLim = s.trailLim[:level]
+	s.qhead = len(s.trail)
+}
+
+func (s *cdclSolver) bumpVar(v int) {
+	s.order.activity[v] += s.varInc
+	if s.order.activity[v] > 1e100 {
+		for i := range s.order.activity {
+			s.order.activity[i] *= 1e-100
+		}
+		s.varInc *= 1e-100
+	}
+	if s.order.contains(v) {
+		s.order.up(v)
+	}
+}
+
+// pickBranch returns the unassigned variable with the highest activity, or 0 if all
+// the variables are assigned.
+func (s *cdclSolver) pickBranch() int {
+	for s.order.len() > 0 {
+		v := s.order.pop()
+		if s.assigns[v] == undef {
+			return v
+		}
+	}
+	return 0
+}
+
+// solve runs the search, returning the model found (model[v] is the value of variable v)
+// or false if the formula is unsatisfiable or the search was cancelled, in which case
+// ctx.Err() is not nil. The context is checked before every propagation.
+func (s *cdclSolver) solve(ctx context.Context) (model []bool, ok bool) {
+	if s.unsat || s.propagate() != nil {
+		return
+	}
+
+	for restart := 1; ; restart++ {
+		budget := 100 * luby(restart)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			conflict := s.propagate()
+
+			if conflict != nil {
+				s.Conflicts++
+				budget--
+				if s.decisionLevel() == 0 {
+					return
+				}
+
+				learnt, backjump := s.analyze(conflict)
+				s.cancelUntil(backjump)
+				if len(learnt) == 1 {
+					s.enqueue(learnt[0], nil)
+				} else {
+					c := &satClause{learnt}
+					s.attach(c)
+					s.enqueue(learnt[0], c)
+				}
+				s.varInc /= 0.95
+				continue
+			}
+
+			if budget <= 0 {
+				s.cancelUntil(0)
+				break
+			}
+			v := s.pickBranch()
+			if v == 0 {
+				model = make([]bool, s.numVars+1)
+				for i := 1; i <= s.numVars; i++ {
+					model[i] = s.assigns[i] == lTrue
+				}
+				ok = true
+				return
+			}
+
+			s.Decisions++
+			s.trailLim = append(s.trailLim, len(s.trail))
+			l := literal(2*v + 1)
+			if s.polarity[v] {
+				l = literal(2 * v)
+			}
+			s.enqueue(l, nil)
+		}
+	}
+}
+
+// luby returns the i-th element (starting from 1) of the Luby sequence 1 1 2 1 1 2 4 ...
+func luby(i int) int {
+	for k := 1; ; k++ {
+		if i == 1<<uint(k)-1 {
+			return 1 << uint(k-1)
+		}
+		if i < 1<<uint(k)-1 {
+			return luby(i - 1<<uint(k-1) + 1)
+		}
+	}
+}
+
+// varHeap is a max-heap of variables ordered by activity.
+type varHeap struct {
+	heap     []int
+	indices  []int
+	activity []float64
+}
+
+func newVarHeap(n int) *varHeap {
+	h := &varHeap{indices: make([]int, n), activity: make([]float64, n)}
+	for i := range h.indices {
+		h.indices[i] = -1
+	}
+	return h
+}
+
+func (h *varHeap) len() int {
+	return len(h.heap)
+}
+
+func (h *varHeap) contains(v int) bool {
+	return h.indices[v] >= 0
+}
+
+func (h *varHeap) push(v int) {
+	h.indices[v] = len(h.heap)
+	h.heap = append(h.heap, v)
+	h.up(v)
+}
+
+func (h *varHeap) pop() int {
+	v := h.heap[0]
+	last := h.heap[len(h.heap)-1]
+	h.heap = h.heap[:len(h.heap)-1]
+	h.indices[v] = -1
+	if len(h.heap) > 0 {
+		h.heap[0] = last
+		h.indices[last] = 0
+		h.down(0)
+	}
+	return v
+}
+
+func (h *varHeap) up(v int) {
+	i := h.indices[v]
+	for i > 0 {
+		parent := (i - 1) / 2
+		if h.activity[h.heap[parent]] >= h.activity[v] {
+			break
+		}
+		h.heap[i] = h.heap[parent]
+		h.indices[h.heap[i]] = i
+		i = parent
+	}
+	h.heap[i] = v
+	h.indices[v] = i
+}
+
+func (h *varHeap) down(i int) {
+	v := h.heap[i]
+	for {
+		child := 2*i + 1
+		if child >= len(h.heap) {
+			break
+		}
+		if child+1 < len(h.heap) && h.activity[h.heap[child+1]] > h.activity[h.heap[child]] {
+			child++
+		}
+		if h.activity[h.heap[child]] <= h.activity[v] {
+			break
+		}
+		h.heap[i] = h.heap[child]
+		h.indices[h.heap[i]] = i
+		i = child
+	}
+	h.heap[i] = v
+	h.indices[v] = i
+}
@@ -0,0 +1,187 @@
+package solver
+
+// CNF is a boolean formula in conjunctive normal form encoding a puzzle.
+//
+// Variables are numbered from 1 as in the DIMACS format, a positive literal is the number
+// of its variable and a negative one its opposite. The first rows*columns variables are
+// the cells of the puzzle in row-major order, a cell is full when its variable is true.
+// They are followed by a variable for each position where a block can start (for each
+// possible length, if the block length is unknown) and by the auxiliary variables used to
+// state that a block starts only once.
+type CNF struct {
+	NumVars    int
+	Clauses    [][]int
+	rows       int
+	columns    int
+	placements []blockPlacement
+}
+
+// blockPlacement is a variable stating that a block of a line starts at a given position
+// with the given length.
+type blockPlacement struct {
+	ltype                   LineType
+	index, block            int
+	start, length, variable int
+}
+
+// EncodeCNF encodes a puzzle as a CNF formula, whose models are the solutions of the puzzle.
+func EncodeCNF(p Puzzle) *CNF {
+	cnf := &CNF{rows: len(p.Rows), columns: len(p.Cols)}
+	cnf.NumVars = cnf.rows * cnf.columns
+
+	for r, constraints := range p.Rows {
+		cells := make([]int, cnf.columns)
+		for c := range cells {
+			cells[c] = cnf.cellVar(r, c)
+		}
+		cnf.encodeLine(row, r, cells, constraints)
+	}
+
+	for c, constraints := range p.Cols {
+		cells := make([]int, cnf.rows)
+		for r := range cells {
+			cells[r] = cnf.cellVar(r, c)
+		}
+		cnf.encodeLine(column, c, cells, constraints)
+	}
+	return cnf
+}
+
+// cellVar returns the variable of the cell at row r and column c.
+func (cnf *CNF) cellVar(r int, c int) int {
+	return r*cnf.columns + c + 1
+}
+
+func (cnf *CNF) newVar() int {
+	cnf.NumVars++
+	return cnf.NumVars
+}
+
+func (cnf *CNF) addClause(lits ...int) {
+	cnf.Clauses = append(cnf.Clauses, lits)
+}
+
+// encodeLine adds the clauses stating that the given cell variables form a line
+// satisfying the constraints.
+func (cnf *CNF) encodeLine(lt LineType, index int, cells []int, constraints []int) {
+	if len(constraints) == 0 {
+		// fully unknown line, any line will do
+		return
+	}
+	if len(constraints) == 1 && constraints[0] == 0 {
+		for _, cell := range cells {
+			cnf.addClause(-cell)
+		}
+		return
+	}
+
+	n, m := len(cells), len(constraints)
+
+	// the shortest space taken by the blocks before and after each block
+	minLength := func(b int) int {
+		if constraints[b] == Unknown {
+			return 1
+		}
+		return constraints[b]
+	}
+	before := make([]int, m)
+	after := make([]int, m)
+	for b := 1; b < m; b++ {
+		before[b] = before[b-1] + minLength(b-1) + 1
+	}
+	for b := m - 2; b >= 0; b-- {
+		after[b] = after[b+1] + minLength(b+1) + 1
+	}
+
+	placements := make([][]blockPlacement, m)
+	covering := make([][]int, n)
+
+	for b := range constraints {
+		lengths := []int{constraints[b]}
+		if constraints[b] == Unknown {
+			lengths = lengths[:0]
+			for l := 1; l <= n; l++ {
+				lengths = append(lengths, l)
+			}
+		}
+
+		for _, l := range lengths {
+			for start := before[b]; start+l <= n-after[b]; start++ {
+				v := cnf.newVar()
+				placement := blockPlacement{lt, index, b, start, l, v}
+				placements[b] = append(placements[b], placement)
+				cnf.placements = append(cnf.placements, placement)
+
+				// the block fills its cells
+				for i := start; i < start+l; i++ {
+					cnf.addClause(-v, cells[i])
+					covering[i] = append(covering[i], v)
+				}
+			}
+		}
+
+		// the block starts exactly once
+		vars := make([]int, len(placements[b]))
+		for i, placement := range placements[b] {
+			vars[i] = placement.variable
+		}
+		cnf.addClause(vars...)
+		cnf.atMostOne(vars)
+	}
+
+	// the next block starts after the end of the current one, leaving a gap
+	for b := 0; b < m-1; b++ {
+		for _, placement := range placements[b] {
+			clause := []int{-placement.variable}
+			for _, next := range placements[b+1] {
+				if next.start > placement.start+placement.length {
+					clause = append(clause, next.variable)
+				}
+			}
+			cnf.addClause(clause...)
+		}
+	}
+
+	// cells not covered by any block are empty
+	for i, cell := range cells {
+		cnf.addClause(append([]int{-cell}, covering[i]...)...)
+	}
+}
+
+// atMostOne adds the clauses stating that at most one of the variables is true, using
+// the sequential counter encoding which needs a linear number of clauses.
+func (cnf *CNF) atMostOne(vars []int) {
+	if len(vars) < 2 {
+		return
+	}
+
+	// counter[i] is true if one of the first i+1 variables is true
+	counter := make([]int, len(vars)-1)
+	for i := range counter {
+		counter[i] = cnf.newVar()
+	}
+
+	cnf.addClause(-vars[0], counter[0])
+	for i := 1; i < len(vars)-1; i++ {
+		cnf.addClause(-vars[i], counter[i])
+		cnf.addClause(-counter[i-1], counter[i])
+		cnf.addClause(-vars[i], -counter[i-1])
+	}
+	cnf.addClause(-vars[len(vars)-1], -counter[len(counter)-1])
+}
+
+// board builds the Board described by a model of the formula, where model[v] is the
+// value of variable v.
+func (cnf *CNF) board(model []bool) Board {
+	board := NewBoard(cnf.rows, cnf.columns)
+	for r := range board {
+		for c := range board[r] {
+			if model[cnf.cellVar(r, c)] {
+				board[r][c] = full
+			} else {
+				board[r][c] = marked
+			}
+		}
+	}
+	return board
+}
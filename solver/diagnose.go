@@ -0,0 +1,169 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Typo is a line of a puzzle whose clue is likely wrong, along with the clue that
+// would make the puzzle solvable.
+type Typo struct {
+	LineType  LineType
+	Index     int
+	Clue      []int
+	Suggested []int
+}
+
+func (typo Typo) String() string {
+	return fmt.Sprintf("%v %d: %v should be %v", typo.LineType, typo.Index, typo.Clue, typo.Suggested)
+}
+
+// Diagnosis is the result of Diagnose: the smallest set of clues that had to be relaxed
+// and the Board obtained by solving the puzzle without them.
+type Diagnosis struct {
+	Typos []Typo
+	Board Board
+}
+
+func (lt LineType) String() string {
+	if lt == row {
+		return "row"
+	}
+	return "column"
+}
+
+// Diagnose looks for the smallest set of row and column clues that, if relaxed, make the
+// puzzle solvable, trying all the sets of up to maxTypos lines.
+//
+// A relaxed clue is treated as a fully unknown line, the suggested correction for it is
+// then read back from the solved Board. When several sets of the same size work, the one
+// whose suggestions are closest to the original clues is chosen.
+// If the puzzle is already solvable the diagnosis has no typos, an error is returned if
+// relaxing maxTypos lines is not enough.
+func Diagnose(p Puzzle, maxTypos int) (d Diagnosis, err error) {
+	lines := len(p.Rows) + len(p.Cols)
+
+	for k := 0; k <= maxTypos && k <= lines; k++ {
+		relaxed := make([]int, k)
+		for i := range relaxed {
+			relaxed[i] = i
+		}
+
+		found, best := false, 0
+		for {
+			if candidate, ok := diagnoseRelaxed(p, relaxed); ok {
+				distance := 0
+				for _, typo := range candidate.Typos {
+					distance += clueDistance(typo.Clue, typo.Suggested)
+				}
+				if !found || distance < best {
+					d, found, best = candidate, true, distance
+				}
+			}
+			if !nextCombination(relaxed, lines) {
+				break
+			}
+		}
+
+		if found {
+			return
+		}
+	}
+
+	err = errors.New("No solution found relaxing the given number of clues")
+	return
+}
+
+// diagnoseRelaxed solves the puzzle ignoring the clues of the given lines, which are
+// indexed with rows first and then columns.
+func diagnoseRelaxed(p Puzzle, relaxed []int) (d Diagnosis, ok bool) {
+	q := Puzzle{Name: p.Name, Rows: make([][]int, len(p.Rows)), Cols: make([][]int, len(p.Cols))}
+	copy(q.Rows, p.Rows)
+	copy(q.Cols, p.Cols)
+
+	for _, i := range relaxed {
+		if i < len(p.Rows) {
+			q.Rows[i] = nil
+		} else {
+			q.Cols[i-len(p.Rows)] = nil
+		}
+	}
+
+	t := NewTreeSolver(q)
+	if !t.solve() {
+		return
+	}
+	ok = true
+	d.Board = t.board.toBoard()
+	d.Typos = make([]Typo, 0, len(relaxed))
+
+	for _, i := range relaxed {
+		var typo Typo
+		if i < len(p.Rows) {
+			typo = Typo{LineType: row, Index: i, Clue: p.Rows[i]}
+		} else {
+			typo = Typo{LineType: column, Index: i - len(p.Rows), Clue: p.Cols[i-len(p.Rows)]}
+		}
+		typo.Suggested = lineClues(t.getLine(typo.LineType, typo.Index))
+		d.Typos = append(d.Typos, typo)
+	}
+	return
+}
+
+// nextCombination advances indexes to the next k-combination of n elements in
+// lexicographic order, returning false after the last one.
+func nextCombination(indexes []int, n int) bool {
+	k := len(indexes)
+	for i := k - 1; i >= 0; i-- {
+		if indexes[i] < n-k+i {
+			indexes[i]++
+			for j := i + 1; j < k; j++ {
+				indexes[j] = indexes[j-1] + 1
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// clueDistance measures how different two clues are, comparing blocks one by one
+// and counting the length of the blocks that appear only in one of them.
+func clueDistance(a []int, b []int) (distance int) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(a):
+			distance += b[i]
+		case i >= len(b):
+			distance += a[i]
+		case a[i] == Unknown:
+		case a[i] > b[i]:
+			distance += a[i] - b[i]
+		default:
+			distance += b[i] - a[i]
+		}
+	}
+	return
+}
+
+// lineClues returns the constraints describing the blocks of full cells in a line.
+// A line without blocks is described by [0].
+func lineClues(line []Cell) []int {
+	clues := make([]int, 0)
+	size := 0
+
+	for _, cell := range line {
+		if cell == full {
+			size++
+		} else if size > 0 {
+			clues = append(clues, size)
+			size = 0
+		}
+	}
+	if size > 0 {
+		clues = append(clues, size)
+	}
+	if len(clues) == 0 {
+		clues = append(clues, 0)
+	}
+	return clues
+}
@@ -0,0 +1,54 @@
+package solver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+
+	d, err := Diagnose(puz, 1)
+	if err != nil || len(d.Typos) != 0 {
+		t.Errorf("Expected no typos, got %v %v", d.Typos, err)
+		t.FailNow()
+	}
+
+	puz.Rows = [][]int{{3}, {1, 1, 1}, {4}, {1, 1}, {3}}
+
+	if _, err = Diagnose(puz, 0); err == nil {
+		t.Errorf("Expected an error without relaxing clues")
+		t.FailNow()
+	}
+
+	d, err = Diagnose(puz, 1)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	expected := []Typo{{LineType: row, Index: 2, Clue: []int{4}, Suggested: []int{5}}}
+	if !reflect.DeepEqual(d.Typos, expected) {
+		t.Errorf("Expected %v, got %v", expected, d.Typos)
+		t.FailNow()
+	}
+}
+
+func TestLineClues(t *testing.T) {
+	line := []Cell{1, 1, 2, 0, 1, 2, 1, 1, 1}
+	expected := []int{2, 1, 3}
+
+	if result := lineClues(line); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+
+	line = []Cell{2, 0, 2}
+	expected = []int{0}
+
+	if result := lineClues(line); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,120 @@
+package solver
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsatisfiable is returned when reading the model of a formula that the SAT solver
+// found to be unsatisfiable.
+var ErrUnsatisfiable = errors.New("The formula is unsatisfiable")
+
+// WriteDIMACS writes the formula in the DIMACS CNF format read by most SAT solvers.
+func (cnf *CNF) WriteDIMACS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "c gongram puzzle %d x %d, cells are variables 1 to %d in row-major order\n",
+		cnf.rows, cnf.columns, cnf.rows*cnf.columns)
+	fmt.Fprintf(bw, "p cnf %d %d\n", cnf.NumVars, len(cnf.Clauses))
+
+	for _, clause := range cnf.Clauses {
+		for _, lit := range clause {
+			fmt.Fprintf(bw, "%d ", lit)
+		}
+		bw.WriteString("0\n")
+	}
+	return bw.Flush()
+}
+
+// WriteVarMap writes what each variable of the formula stands for, one variable per line:
+//
+//	cell <variable> <row> <column>
+//	block <variable> <row|column> <line index> <block index> <start> <length>
+//
+// The auxiliary variables of the encoding are left out. Lines starting with c are comments.
+func (cnf *CNF) WriteVarMap(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "c gongram variable map for a %d x %d puzzle\n", cnf.rows, cnf.columns)
+	for r := 0; r < cnf.rows; r++ {
+		for c := 0; c < cnf.columns; c++ {
+			fmt.Fprintf(bw, "cell %d %d %d\n", cnf.cellVar(r, c), r, c)
+		}
+	}
+	for _, p := range cnf.placements {
+		fmt.Fprintf(bw, "block %d %v %d %d %d %d\n", p.variable, p.ltype, p.index, p.block, p.start, p.length)
+	}
+	return bw.Flush()
+}
+
+// ReadModel reads the output of a SAT solver for the formula, returning the value of
+// each variable (model[v] is the value of variable v).
+//
+// It accepts the competition format, with an s line for the result and v lines for the
+// literals, as well as the bare list of literals written by solvers like MiniSat, with an
+// optional SAT or UNSAT first line. Variables missing from the output are false, except
+// for the cells of the puzzle which must all be assigned. If the solver found no solution
+// ErrUnsatisfiable is returned.
+func (cnf *CNF) ReadModel(r io.Reader) (model []bool, err error) {
+	model = make([]bool, cnf.NumVars+1)
+	assigned := make([]bool, cnf.NumVars+1)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+
+		switch strings.Join(fields, " ") {
+		case "s UNSATISFIABLE", "UNSAT":
+			return nil, ErrUnsatisfiable
+		case "s SATISFIABLE", "SAT":
+			continue
+		}
+		if fields[0] == "v" {
+			fields = fields[1:]
+		}
+
+		for _, field := range fields {
+			lit, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid literal %q in the model", field)
+			}
+			v := lit
+			if v < 0 {
+				v = -v
+			}
+			if v > cnf.NumVars {
+				return nil, fmt.Errorf("Variable %d is out of range, the formula has %d variables", v, cnf.NumVars)
+			}
+			model[v] = lit > 0
+			assigned[v] = lit != 0
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for v := 1; v <= cnf.rows*cnf.columns; v++ {
+		if !assigned[v] {
+			return nil, fmt.Errorf("Cell variable %d is not assigned in the model", v)
+		}
+	}
+	return model, nil
+}
+
+// ReadSolution reads the output of a SAT solver for the formula with ReadModel,
+// returning the Board it describes.
+func (cnf *CNF) ReadSolution(r io.Reader) (Board, error) {
+	model, err := cnf.ReadModel(r)
+	if err != nil {
+		return nil, err
+	}
+	return cnf.board(model), nil
+}
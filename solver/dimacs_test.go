@@ -0,0 +1,119 @@
+package solver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReadSolution checks the encoding against the TreeSolver: the model found for each
+// bundled puzzle, written in the format of an external SAT solver and read back, must
+// be the solution found by the TreeSolver, and it must be the only one.
+func TestReadSolution(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		cnf := EncodeCNF(puz)
+		model, ok := newCDCLSolver(cnf).solve(context.Background())
+		if !ok {
+			t.Errorf("Puzzle %s was not solved", puz.Name)
+			t.FailNow()
+		}
+
+		var output bytes.Buffer
+		output.WriteString("c external solver output\ns SATISFIABLE\nv")
+		for v := 1; v <= cnf.NumVars; v++ {
+			if !model[v] {
+				output.WriteString(" -")
+			} else {
+				output.WriteString(" ")
+			}
+			fmt.Fprint(&output, v)
+		}
+		output.WriteString(" 0\n")
+
+		board, err := cnf.ReadSolution(&output)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+
+		expected := NewTreeSolver(puz).Solve()
+		if hasUnknownClues(puz) {
+			// the unknown clues may allow other solutions
+			continue
+		}
+		if !reflect.DeepEqual(board, expected) {
+			t.Errorf("Puzzle %s: expected\n%v\ngot\n%v", puz.Name, expected, board)
+			t.FailNow()
+		}
+
+		// blocking the solution leaves no model
+		blocking := make([]int, 0, cnf.rows*cnf.columns)
+		for r := range expected {
+			for c := range expected[r] {
+				if expected[r][c] == full {
+					blocking = append(blocking, -cnf.cellVar(r, c))
+				} else {
+					blocking = append(blocking, cnf.cellVar(r, c))
+				}
+			}
+		}
+		cnf.addClause(blocking...)
+		if _, ok := newCDCLSolver(cnf).solve(context.Background()); ok {
+			t.Errorf("Puzzle %s: the encoding allows more than one solution", puz.Name)
+			t.FailNow()
+		}
+	}
+}
+
+func TestReadModel(t *testing.T) {
+	puz := Puzzle{Name: "corner", Rows: [][]int{{1}, {0}}, Cols: [][]int{{1}, {0}}}
+	cnf := EncodeCNF(puz)
+
+	board, err := cnf.ReadSolution(strings.NewReader("SAT\n1 -2 -3\n-4 0\n"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if expected := (Board{{full, marked}, {marked, marked}}); !reflect.DeepEqual(board, expected) {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, board)
+		t.FailNow()
+	}
+
+	if _, err = cnf.ReadModel(strings.NewReader("s UNSATISFIABLE\n")); !errors.Is(err, ErrUnsatisfiable) {
+		t.Errorf("Expected ErrUnsatisfiable, got %v", err)
+		t.FailNow()
+	}
+
+	if _, err = cnf.ReadModel(strings.NewReader("v 1 -2 0\n")); err == nil {
+		t.Errorf("Expected an error for unassigned cells")
+		t.FailNow()
+	}
+
+	if _, err = cnf.ReadModel(strings.NewReader("v 1 -2 -3 -4 x 0\n")); err == nil {
+		t.Errorf("Expected an error for an invalid literal")
+		t.FailNow()
+	}
+}
+
+func TestWriteVarMap(t *testing.T) {
+	puz := Puzzle{Name: "corner", Rows: [][]int{{1}, {0}}, Cols: [][]int{{1}, {0}}}
+	var buf bytes.Buffer
+
+	if err := EncodeCNF(puz).WriteVarMap(&buf); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	for _, line := range []string{"cell 1 0 0", "cell 4 1 1", "block 5 row 0 0 0 1", "block 8 column 0 0 0 1"} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("Missing %q in\n%s", line, buf.String())
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A Move changes the cell at row Row and column Col of the board of a Game from the
+// value From to the value To.
+type Move struct {
+	Row  int  `json:"row"`
+	Col  int  `json:"col"`
+	From Cell `json:"from"`
+	To   Cell `json:"to"`
+}
+
+// A Game is a puzzle being solved by a player: it keeps the board filled in so far,
+// the cursor and the moves that can be undone and redone. The board is solved when it
+// satisfies all the clues, while mistakes can only be told when the puzzle has a unique
+// solution.
+type Game struct {
+	Puzzle Puzzle
+	Board  Board
+	// Row and Col are the position of the cursor
+	Row int
+	Col int
+	// Hints is the number of hints given
+	Hints int
+
+	solution Board
+	// unique is true if the solution is known to be the only one
+	unique bool
+	undo   []Move
+	redo   []Move
+	// the time played before the game was resumed, and when it was
+	elapsed time.Duration
+	started time.Time
+}
+
+// NewGame starts a game with an empty board, solving the puzzle to check the moves
+// of the player against. The puzzle must have a solution, found before ctx is done.
+// The search for a second one stops with ctx too, leaving the solution not known to be
+// unique, so that the mistakes aren't counted.
+func NewGame(ctx context.Context, p Puzzle) (*Game, error) {
+	solution, unique, ok := gameSolution(ctx, p)
+	if !ok && ctx.Err() != nil {
+		return nil, errors.New("No solution was found in time")
+	}
+	if !ok {
+		return nil, errors.New("The puzzle has no solution")
+	}
+	return newGame(p, solution, unique), nil
+}
+
+func newGame(p Puzzle, solution Board, unique bool) *Game {
+	return &Game{Puzzle: p, Board: NewBoard(len(p.Rows), len(p.Cols)), solution: solution, unique: unique, started: time.Now()}
+}
+
+// gameSolution finds a solution of the puzzle, looking for a second one to tell if it's
+// unique until ctx is cancelled. It returns false if no solution was found.
+func gameSolution(ctx context.Context, p Puzzle) (solution Board, unique bool, ok bool) {
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	count := 0
+	for board := range NewTreeSolver(p).Solutions(searchCtx) {
+		if count++; count > 1 {
+			cancel()
+			break
+		}
+		solution = board
+	}
+	// the whole tree must have been explored to know that there's no other solution
+	return solution, count == 1 && ctx.Err() == nil, count > 0
+}
+
+// Elapsed returns the time spent playing the game, including the time before it
+// was saved and resumed.
+func (g *Game) Elapsed() time.Duration {
+	return g.elapsed + time.Since(g.started)
+}
+
+// MoveCursor moves the cursor by the given number of rows and columns, wrapping
+// around the edges of the board.
+func (g *Game) MoveCursor(rows int, cols int) {
+	n, m := len(g.Board), len(g.Board[0])
+	g.Row = ((g.Row+rows)%n + n) % n
+	g.Col = ((g.Col+cols)%m + m) % m
+}
+
+// Fill fills the cell under the cursor, or empties it if it's already full.
+func (g *Game) Fill() {
+	g.toggle(full)
+}
+
+// Mark marks the cell under the cursor as not part of the picture, or empties it if
+// it's already marked.
+func (g *Game) Mark() {
+	g.toggle(marked)
+}
+
+func (g *Game) toggle(value Cell) {
+	if g.Board[g.Row][g.Col] == value {
+		value = empty
+	}
+	g.play(Move{Row: g.Row, Col: g.Col, From: g.Board[g.Row][g.Col], To: value})
+}
+
+// play makes a move that can be undone, discarding the moves undone before it.
+func (g *Game) play(m Move) {
+	g.Board[m.Row][m.Col] = m.To
+	g.undo = append(g.undo, m)
+	g.redo = g.redo[:0]
+}
+
+// Moves returns the moves played so far that have not been undone, oldest first.
+func (g *Game) Moves() []Move {
+	return g.undo
+}
+
+// Undo undoes the last move, moving the cursor on its cell. It returns false if
+// there's nothing to undo.
+func (g *Game) Undo() bool {
+	if len(g.undo) == 0 {
+		return false
+	}
+	m := g.undo[len(g.undo)-1]
+	g.undo = g.undo[:len(g.undo)-1]
+	g.redo = append(g.redo, m)
+	g.Board[m.Row][m.Col] = m.From
+	g.Row, g.Col = m.Row, m.Col
+	return true
+}
+
+// Redo plays again the last move undone, moving the cursor on its cell. It returns
+// false if there's nothing to redo.
+func (g *Game) Redo() bool {
+	if len(g.redo) == 0 {
+		return false
+	}
+	m := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	g.undo = append(g.undo, m)
+	g.Board[m.Row][m.Col] = m.To
+	g.Row, g.Col = m.Row, m.Col
+	return true
+}
+
+// Mistakes returns the number of cells that are full but should be marked or the
+// other way round, the cells left empty aren't counted. It's always 0 if the puzzle
+// has more than one solution, since a cell can be right in one and wrong in another.
+func (g *Game) Mistakes() (count int) {
+	for r := range g.Board {
+		for c, value := range g.Board[r] {
+			if g.wrong(r, c, value) {
+				count++
+			}
+		}
+	}
+	return
+}
+
+func (g *Game) wrong(r int, c int, value Cell) bool {
+	return g.unique && value != empty && (value == full) != (g.solution[r][c] == full)
+}
+
+// Solved returns true if the full cells of the board make up the blocks of the clues of
+// every line, the other cells don't need to be marked.
+func (g *Game) Solved() bool {
+	for i, clues := range g.Puzzle.Rows {
+		if !lineMatches(clues, g.line(row, i)) {
+			return false
+		}
+	}
+	for i, clues := range g.Puzzle.Cols {
+		if !lineMatches(clues, g.line(column, i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hint plays a move for the player, moving the cursor on its cell, and describes it.
+//
+// If there's a mistake on the board its cell is corrected, otherwise the hint fills in
+// a cell that can be deduced from the clues of a single line and the cells around it.
+// When no line can make progress on its own a cell of the solution is revealed. Only the
+// last kind of hint is given by the solution, which is one of several if it's not unique.
+func (g *Game) Hint() string {
+	g.Hints++
+
+	for r := range g.Board {
+		for c, value := range g.Board[r] {
+			if g.wrong(r, c, value) {
+				g.reveal(r, c)
+				return fmt.Sprintf("The cell at row %d, column %d was wrong", r+1, c+1)
+			}
+		}
+	}
+
+	for _, lt := range []LineType{row, column} {
+		clues := g.Puzzle.Rows
+		if lt == column {
+			clues = g.Puzzle.Cols
+		}
+		for i, constraints := range clues {
+			line := g.line(lt, i)
+			result, ok := SolveLine(constraints, line)
+			if !ok {
+				continue
+			}
+			for j := range line {
+				if line[j] == empty && result[j] != empty {
+					r, c := i, j
+					if lt == column {
+						r, c = j, i
+					}
+					g.hint(r, c, result[j])
+					return fmt.Sprintf("The clues of %v %d tell this cell is %v", lt, i+1, g.Board[r][c])
+				}
+			}
+		}
+	}
+
+	for r := range g.Board {
+		for c, value := range g.Board[r] {
+			if value == empty {
+				g.reveal(r, c)
+				return fmt.Sprintf("No line can be solved on its own, revealed row %d, column %d", r+1, c+1)
+			}
+		}
+	}
+	return "The puzzle is already solved"
+}
+
+// reveal sets the cell to its value in the solution.
+func (g *Game) reveal(r int, c int) {
+	value := marked
+	if g.solution[r][c] == full {
+		value = full
+	}
+	g.hint(r, c, value)
+}
+
+// hint sets the cell to the given value, moving the cursor on it.
+func (g *Game) hint(r int, c int, value Cell) {
+	g.Row, g.Col = r, c
+	g.play(Move{Row: r, Col: c, From: g.Board[r][c], To: value})
+}
+
+func (g *Game) line(lt LineType, index int) []Cell {
+	if lt == row {
+		return g.Board[index]
+	}
+	line := make([]Cell, len(g.Board))
+	for r := range g.Board {
+		line[r] = g.Board[r][index]
+	}
+	return line
+}
+
+// LineDone returns true if the full cells of the line make up the blocks of its
+// clues. Lines without clues are never done.
+func (g *Game) LineDone(lt LineType, index int) bool {
+	constraints := g.Puzzle.Rows
+	if lt == column {
+		constraints = g.Puzzle.Cols
+	}
+	clues := constraints[index]
+	return len(clues) > 0 && lineMatches(clues, g.line(lt, index))
+}
+
+// lineMatches returns true if the full cells of the line make up the blocks of the
+// clues, any line matches a fully unknown one.
+func lineMatches(clues []int, line []Cell) bool {
+	if len(clues) == 0 {
+		return true
+	}
+	blocks := lineClues(line)
+	if len(blocks) != len(clues) {
+		return false
+	}
+	for i, block := range blocks {
+		// an unknown block still needs at least a full cell
+		if clues[i] != block && (clues[i] != Unknown || block == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// ANSI escape sequences used by Game.Render.
+const (
+	ansiCursor = "\x1b[7m"
+	ansiDone   = "\x1b[2m"
+	ansiWrong  = "\x1b[31m"
+)
+
+// Render draws the board for a terminal like RenderBoard, with the clues of the lines
+// that are done dimmed and the cell under the cursor in reverse video. If check is true
+// the mistakes are shown in red. Lines end with \r\n, as needed in raw mode.
+func (g *Game) Render(check bool) string {
+	rowClues := make([]string, len(g.Puzzle.Rows))
+	width := 0
+	for i, constraints := range g.Puzzle.Rows {
+		rowClues[i] = strings.Join(clueStrings(constraints), " ")
+		width = max(width, len(rowClues[i]))
+	}
+	colClues := make([][]string, len(g.Puzzle.Cols))
+	height := 0
+	for i, constraints := range g.Puzzle.Cols {
+		colClues[i] = clueStrings(constraints)
+		height = max(height, len(colClues[i]))
+	}
+
+	var b strings.Builder
+	for h := 0; h < height; h++ {
+		fmt.Fprintf(&b, "%*s", width+3, "")
+		for c, clues := range colClues {
+			text := "  "
+			if i := h - (height - len(clues)); i >= 0 {
+				text = fmt.Sprintf("%2s", clues[i])
+			}
+			if g.LineDone(column, c) {
+				text = ansiDone + text + ansiReset
+			}
+			b.WriteString(text + " ")
+		}
+		b.WriteString("\r\n")
+	}
+
+	for r, line := range g.Board {
+		text := fmt.Sprintf("%*s", width, rowClues[r])
+		if g.LineDone(row, r) {
+			text = ansiDone + text + ansiReset
+		}
+		b.WriteString(text + " ⎹ ")
+		for c, cell := range line {
+			symbol := cellText(cell)
+			if check && g.wrong(r, c, cell) {
+				symbol = ansiWrong + symbol + ansiReset
+			}
+			if r == g.Row && c == g.Col {
+				symbol = ansiCursor + symbol + ansiReset
+			}
+			b.WriteString(symbol)
+		}
+		b.WriteString(" ⎸\r\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package solver
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGameMoves(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	g, err := NewGame(context.Background(), puz)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	g.MoveCursor(-1, 1)
+	if g.Row != 4 || g.Col != 1 {
+		t.Errorf("Expected the cursor to wrap to (4, 1), got (%d, %d)", g.Row, g.Col)
+		t.FailNow()
+	}
+	g.MoveCursor(0, -1)
+	g.Fill()
+	g.Mark()
+	g.MoveCursor(0, 1)
+	g.Fill()
+	if g.Board[4][0] != marked || g.Board[4][1] != full || len(g.Moves()) != 3 {
+		t.Errorf("Unexpected board\n%v", g.Board)
+		t.FailNow()
+	}
+	if g.Mistakes() != 0 {
+		t.Errorf("Expected no mistakes, got %d", g.Mistakes())
+		t.FailNow()
+	}
+
+	g.Undo()
+	g.Undo()
+	if g.Board[4][0] != full || g.Board[4][1] != empty || g.Row != 4 || g.Col != 0 {
+		t.Errorf("Undo didn't restore the board\n%v", g.Board)
+		t.FailNow()
+	}
+	if g.Mistakes() != 1 {
+		t.Errorf("Expected a mistake, got %d", g.Mistakes())
+		t.FailNow()
+	}
+	g.Redo()
+	if g.Board[4][0] != marked {
+		t.Errorf("Redo didn't play the move again\n%v", g.Board)
+		t.FailNow()
+	}
+
+	// a new move discards the moves undone
+	g.Fill()
+	if g.Redo() {
+		t.Errorf("Expected nothing to redo")
+		t.FailNow()
+	}
+	g.Undo()
+	g.Undo()
+	g.Undo()
+	if g.Undo() || len(g.Moves()) != 0 {
+		t.Errorf("Expected nothing to undo")
+		t.FailNow()
+	}
+}
+
+func TestGameHints(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	g, _ := NewGame(context.Background(), puz)
+
+	// a wrong cell is corrected first
+	g.Fill()
+	if hint := g.Hint(); !strings.Contains(hint, "wrong") || g.Board[0][0] != marked {
+		t.Errorf("Expected the mistake to be corrected, got %q\n%v", hint, g.Board)
+		t.FailNow()
+	}
+
+	for i := 0; !g.Solved(); i++ {
+		if i == 25 {
+			t.Errorf("Hints didn't solve the puzzle\n%v", g.Board)
+			t.FailNow()
+		}
+		g.Hint()
+	}
+	if g.Hints > 25 || g.Mistakes() != 0 {
+		t.Errorf("Unexpected %d hints and %d mistakes", g.Hints, g.Mistakes())
+		t.FailNow()
+	}
+	for i := range puz.Rows {
+		if !g.LineDone(Row, i) || !g.LineDone(Column, i) {
+			t.Errorf("Expected all the lines to be done")
+			t.FailNow()
+		}
+	}
+}
+
+func TestGameRender(t *testing.T) {
+	puz := Puzzle{Name: "test", Rows: [][]int{{1}, {Unknown}}, Cols: [][]int{{2}, nil}}
+	g, _ := NewGame(context.Background(), puz)
+
+	g.Fill()
+	g.MoveCursor(1, 0)
+	if g.LineDone(Row, 1) {
+		t.Errorf("An unknown block can't be empty")
+		t.FailNow()
+	}
+	g.Fill()
+	screen := g.Render(false)
+
+	// both rows and the first column are done, the other column has no clues
+	for _, s := range []string{ansiDone + "1" + ansiReset, ansiDone + " 2" + ansiReset, ansiCursor + " ▉ " + ansiReset} {
+		if !strings.Contains(screen, s) {
+			t.Errorf("Missing %q in\n%q", s, screen)
+			t.FailNow()
+		}
+	}
+	if strings.Count(screen, ansiDone) != 3 || strings.Count(screen, "\r\n") != 3 {
+		t.Errorf("Unexpected screen\n%q", screen)
+		t.FailNow()
+	}
+}
+
+func TestGameAmbiguous(t *testing.T) {
+	// the two diagonals are both solutions
+	puz := Puzzle{Name: "diagonals", Rows: [][]int{{1}, {1}}, Cols: [][]int{{1}, {1}}}
+	g, _ := NewGame(context.Background(), puz)
+
+	// fill the diagonal that the solver didn't find
+	c := 0
+	if g.solution[0][0] == full {
+		c = 1
+	}
+	g.MoveCursor(0, c)
+	g.Fill()
+	g.MoveCursor(1, 1)
+	g.Fill()
+
+	if !g.Solved() || g.Mistakes() != 0 {
+		t.Errorf("Expected any solution to be accepted\n%v", g.Board)
+		t.FailNow()
+	}
+
+	smiley := Puzzle{Rows: [][]int{{3}, {1, 1, 1}, {5}, {1, 1}, {3}}, Cols: [][]int{{3}, {1, 1, 1}, {3, 1}, {1, 1, 1}, {3}}}
+	if g, _ = NewGame(context.Background(), smiley); !g.unique {
+		t.Errorf("Expected the solution to be unique")
+		t.FailNow()
+	}
+}
+
+func TestGameAmbiguousHint(t *testing.T) {
+	puz := Puzzle{Name: "diagonals", Rows: [][]int{{1}, {1}}, Cols: [][]int{{1}, {1}}}
+	g, _ := NewGame(context.Background(), puz)
+
+	// start the diagonal that the solver didn't find, the hints must follow it
+	c := 0
+	if g.solution[0][0] == full {
+		c = 1
+	}
+	g.MoveCursor(0, c)
+	g.Fill()
+
+	hint := g.Hint()
+	if g.Row != 0 || g.Col != 1-c || g.Board[0][1-c] != marked || !strings.Contains(hint, "row 1") {
+		t.Errorf("Expected the other cell of row 1 to be marked, got %q\n%v", hint, g.Board)
+		t.FailNow()
+	}
+	for i := 0; i < 2; i++ {
+		g.Hint()
+	}
+	if !g.Solved() || g.Board[1][1-c] != full {
+		t.Errorf("Expected the hints to complete the diagonal started\n%v", g.Board)
+		t.FailNow()
+	}
+}
+
+func TestNewGameCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	puz := Puzzle{Rows: [][]int{{1}}, Cols: [][]int{{1}}}
+	if _, err := NewGame(ctx, puz); err == nil {
+		t.Errorf("Expected the game not to start without a solution")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,173 @@
+package solver
+
+import (
+	"errors"
+	"html/template"
+	"io"
+)
+
+// WriteHTML writes a single self-contained HTML page to play the puzzle in a browser:
+// clicking a cell fills it, right-clicking marks it, and the board is solved once the
+// full cells make up the blocks of the clues of every line, so that any solution of an
+// ambiguous puzzle is accepted.
+//
+// The puzzle is solved first with a TreeSolver, it must have a solution.
+func WriteHTML(w io.Writer, p Puzzle) error {
+	if !NewTreeSolver(p).solve() {
+		return errors.New("The puzzle has no solution")
+	}
+
+	data := struct {
+		Name     string
+		Rows     [][]string
+		Cols     [][]string
+		RowClues [][]int
+		ColClues [][]int
+	}{
+		Name:     p.Name,
+		Rows:     make([][]string, len(p.Rows)),
+		Cols:     make([][]string, len(p.Cols)),
+		RowClues: p.Rows,
+		ColClues: p.Cols,
+	}
+	for i, constraints := range p.Rows {
+		data.Rows[i] = clueStrings(constraints)
+	}
+	for i, constraints := range p.Cols {
+		data.Cols[i] = clueStrings(constraints)
+	}
+	return htmlTemplate.Execute(w, data)
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; user-select: none; }
+td { width: 24px; height: 24px; padding: 0; text-align: center; font-size: 13px; }
+td.cell { border: 1px solid #bbb; cursor: pointer; }
+td.full { background: #222; }
+td.marked::after { content: "×"; color: #888; }
+td.bold-left { border-left: 2px solid #000; }
+td.bold-top { border-top: 2px solid #000; }
+td.bold-right { border-right: 2px solid #000; }
+td.bold-bottom { border-bottom: 2px solid #000; }
+td.clue-row { text-align: right; padding-right: 6px; white-space: nowrap; }
+td.clue-col { vertical-align: bottom; line-height: 1.3; }
+#status { margin-top: 1em; min-height: 1.5em; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<table id="board"></table>
+<p id="status"></p>
+<button id="check">Check</button>
+<button id="clear">Clear</button>
+<script>
+"use strict";
+var rows = {{.Rows}};
+var cols = {{.Cols}};
+// the clues to check, unknown block lengths are -1 and fully unknown lines null
+var rowClues = {{.RowClues}};
+var colClues = {{.ColClues}};
+
+// the cells are 0 if empty, 1 if full and 2 if marked
+var cells = [];
+var table = document.getElementById("board");
+var message = document.getElementById("status");
+var painting = null;
+
+var header = table.insertRow();
+header.insertCell();
+cols.forEach(function(clues, c) {
+  var td = header.insertCell();
+  td.className = "clue-col";
+  td.innerHTML = clues.join("<br>");
+});
+rows.forEach(function(clues, r) {
+  var tr = table.insertRow();
+  var td = tr.insertCell();
+  td.className = "clue-row";
+  td.textContent = clues.join(" ");
+  cells.push([]);
+  cols.forEach(function(_, c) {
+    var td = tr.insertCell();
+    td.className = "cell";
+    if (c % 5 == 0) td.classList.add("bold-left");
+    if (r % 5 == 0) td.classList.add("bold-top");
+    if (c == cols.length - 1) td.classList.add("bold-right");
+    if (r == rows.length - 1) td.classList.add("bold-bottom");
+    td.addEventListener("mousedown", function(e) {
+      var value = e.button == 2 ? 2 : 1;
+      painting = cells[r][c] == value ? 0 : value;
+      set(r, c, painting);
+      e.preventDefault();
+    });
+    td.addEventListener("mouseenter", function() {
+      if (painting !== null) set(r, c, painting);
+    });
+    cells[r].push(0);
+  });
+});
+table.addEventListener("contextmenu", function(e) { e.preventDefault(); });
+document.addEventListener("mouseup", function() {
+  if (painting !== null) {
+    painting = null;
+    check(false);
+  }
+});
+
+function set(r, c, value) {
+  cells[r][c] = value;
+  var td = table.rows[r + 1].cells[c + 1];
+  td.classList.toggle("full", value == 1);
+  td.classList.toggle("marked", value == 2);
+}
+
+function check(explicit) {
+  var solved = rowClues.every(function(clues, r) {
+    return matches(clues, cells[r]);
+  }) && colClues.every(function(clues, c) {
+    return matches(clues, cells.map(function(line) { return line[c]; }));
+  });
+  if (solved) {
+    message.textContent = "Solved!";
+  } else {
+    message.textContent = explicit ? "Not solved yet." : "";
+  }
+}
+
+document.getElementById("check").addEventListener("click", function() { check(true); });
+document.getElementById("clear").addEventListener("click", function() {
+  cells.forEach(function(line, r) {
+    line.forEach(function(_, c) { set(r, c, 0); });
+  });
+  message.textContent = "";
+});
+
+// matches tells if the full cells of the line make up the blocks of the clues.
+function matches(clues, line) {
+  if (!clues || clues.length == 0) return true;
+  var blocks = [], size = 0;
+  line.concat([0]).forEach(function(v) {
+    if (v == 1) {
+      size++;
+    } else if (size > 0) {
+      blocks.push(size);
+      size = 0;
+    }
+  });
+  if (blocks.length == 0) blocks.push(0);
+  if (blocks.length != clues.length) return false;
+  return clues.every(function(clue, i) {
+    // an unknown block still needs at least a full cell
+    return clue == blocks[i] || (clue == -1 && blocks[i] > 0);
+  });
+}
+</script>
+</body>
+</html>
+`))
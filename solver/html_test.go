@@ -0,0 +1,63 @@
+package solver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, puz); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	page := buf.String()
+
+	for _, s := range []string{
+		`var rows = [["3"],["1","1","1"],["5"],["1","1"],["3"]];`,
+		`var rowClues = [[3],[1,1,1],[5],[1,1],[3]];`,
+		"<title>smiley</title>",
+	} {
+		if !strings.Contains(page, s) {
+			t.Errorf("Missing %s in\n%s", s, page)
+			t.FailNow()
+		}
+	}
+
+	// unknown clues are checked as such
+	puz = Puzzle{Name: "unknown", Rows: [][]int{{Unknown}, nil}, Cols: [][]int{{1}, {1}}}
+	buf.Reset()
+	if err := WriteHTML(&buf, puz); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if s := `var rowClues = [[-1],null];`; !strings.Contains(buf.String(), s) {
+		t.Errorf("Missing %s in\n%s", s, buf.String())
+		t.FailNow()
+	}
+}
+
+func TestWriteHTMLUnsolvable(t *testing.T) {
+	puz := Puzzle{Name: "<broken>", Rows: [][]int{{1}}, Cols: [][]int{{0}}}
+
+	if err := WriteHTML(&bytes.Buffer{}, puz); err == nil {
+		t.Errorf("Expected an error for a puzzle without solution")
+		t.FailNow()
+	}
+
+	// the name is escaped everywhere
+	puz.Cols = [][]int{{1}}
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, puz); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if strings.Contains(buf.String(), "<broken>") {
+		t.Errorf("The name is not escaped in\n%s", buf.String())
+		t.FailNow()
+	}
+}
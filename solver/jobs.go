@@ -0,0 +1,397 @@
+package solver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The states of a Job: it waits in the queue until a worker runs it, then it's done if
+// a solution was found or failed otherwise, unless it was cancelled before that.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// A Job is a puzzle solved in the background by a JobQueue, encoded in JSON as returned
+// by the server and stored in the results directory.
+type Job struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Name       string     `json:"name"`
+	PuzzleHash string     `json:"puzzleHash"`
+	Submitted  time.Time  `json:"submitted"`
+	Started    *time.Time `json:"started,omitempty"`
+	Finished   *time.Time `json:"finished,omitempty"`
+	// Board and Stats are set once the job is done, Error once it failed
+	Board []string  `json:"board,omitempty"`
+	Stats *Stats    `json:"stats,omitempty"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+// JobOptions configures a JobQueue, only the directory is required.
+type JobOptions struct {
+	// Dir is the directory where the results of the jobs are stored, one JSON file
+	// for each job named after its ID
+	Dir string
+	// Workers is the number of jobs run at the same time, one per CPU if zero or less
+	Workers int
+	// MaxQueued is the number of jobs that can wait in the queue, 10000 if zero or less
+	MaxQueued int
+	// Timeout is the time given to the solver for each job, a minute if zero or less
+	Timeout time.Duration
+	// ErrorLog logs the jobs that couldn't be stored, the standard logger is used if nil
+	ErrorLog *log.Logger
+}
+
+// ErrQueueFull is returned by JobQueue.Submit when too many jobs are waiting.
+var ErrQueueFull = errors.New("Too many jobs are waiting in the queue")
+
+// ErrQueueClosed is returned by JobQueue.Submit once the queue is closed.
+var ErrQueueClosed = errors.New("The job queue is closed")
+
+// A JobQueue solves puzzles in the background with a fixed number of workers. The jobs
+// waiting or running are kept in memory, while finished jobs are only kept in the
+// results directory, where they can still be read after a restart. Jobs that couldn't
+// be stored stay in memory until the queue is closed.
+type JobQueue struct {
+	options JobOptions
+	// ready wakes a worker when jobs are added to waiting
+	ready   chan struct{}
+	ctx     context.Context
+	stop    context.CancelFunc
+	workers sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*queuedJob
+	// waiting are the jobs queued and not cancelled, oldest first
+	waiting []*queuedJob
+}
+
+// queuedJob is a job not finished yet, along with its puzzle and the function that
+// cancels it while it's running.
+type queuedJob struct {
+	Job
+	puzzle Puzzle
+	cancel context.CancelFunc
+}
+
+// NewJobQueue creates the results directory if needed and starts the workers of the queue.
+func NewJobQueue(options JobOptions) (*JobQueue, error) {
+	if options.Workers <= 0 {
+		options.Workers = runtime.NumCPU()
+	}
+	if options.MaxQueued <= 0 {
+		options.MaxQueued = 10000
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = time.Minute
+	}
+	if options.ErrorLog == nil {
+		options.ErrorLog = log.Default()
+	}
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	q := &JobQueue{options: options, ready: make(chan struct{}, 1), jobs: make(map[string]*queuedJob)}
+	q.ctx, q.stop = context.WithCancel(context.Background())
+	for i := 0; i < options.Workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	return q, nil
+}
+
+// Close stops the workers, cancelling the jobs running and the ones still waiting, and
+// stores all the jobs left in memory.
+func (q *JobQueue) Close() {
+	q.stop()
+	q.workers.Wait()
+
+	q.mu.Lock()
+	left := make([]*queuedJob, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		if j.Status == JobQueued {
+			j.Status = JobCancelled
+		}
+		left = append(left, j)
+	}
+	q.waiting = nil
+	q.mu.Unlock()
+
+	for _, j := range left {
+		q.finish(j)
+	}
+}
+
+// Submit adds a job solving each puzzle to the queue, in order. Either all the puzzles
+// are queued or none is, failing with ErrQueueFull if there isn't room for all of them.
+func (q *JobQueue) Submit(puzzles ...Puzzle) ([]Job, error) {
+	queued := make([]*queuedJob, len(puzzles))
+	now := time.Now()
+	for i, p := range puzzles {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		queued[i] = &queuedJob{puzzle: p}
+		queued[i].Job = Job{
+			ID:         hex.EncodeToString(id),
+			Status:     JobQueued,
+			Name:       p.Name,
+			PuzzleHash: PuzzleHash(p),
+			Submitted:  now,
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ctx.Err() != nil {
+		return nil, ErrQueueClosed
+	}
+	if len(q.waiting)+len(queued) > q.options.MaxQueued {
+		return nil, ErrQueueFull
+	}
+	jobs := make([]Job, len(queued))
+	for i, j := range queued {
+		q.jobs[j.ID] = j
+		jobs[i] = j.Job
+	}
+	q.waiting = append(q.waiting, queued...)
+	q.wake()
+	return jobs, nil
+}
+
+// wake tells a worker that jobs are waiting, unless one has already been told.
+func (q *JobQueue) wake() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Get returns the job with the given ID, reading it from the results directory if it's
+// finished.
+func (q *JobQueue) Get(id string) (Job, error) {
+	q.mu.Lock()
+	j, ok := q.jobs[id]
+	var job Job
+	if ok {
+		job = j.Job
+	}
+	q.mu.Unlock()
+	if ok {
+		return job, nil
+	}
+
+	data, err := os.ReadFile(q.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = errNotFound("No job found with the given ID")
+		}
+		return job, err
+	}
+	err = json.Unmarshal(data, &job)
+	return job, err
+}
+
+// Cancel cancels the job with the given ID if it's waiting or running, returning it.
+// Jobs cancelled while waiting leave room in the queue right away. Finished jobs are
+// returned as they are.
+func (q *JobQueue) Cancel(id string) (Job, error) {
+	q.mu.Lock()
+	j, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return q.Get(id)
+	}
+
+	switch j.Status {
+	case JobQueued:
+		j.Status = JobCancelled
+		for i, w := range q.waiting {
+			if w == j {
+				q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+				break
+			}
+		}
+		q.mu.Unlock()
+		return q.finish(j), nil
+	case JobRunning:
+		j.Status = JobCancelled
+		j.cancel()
+	}
+	job := j.Job
+	q.mu.Unlock()
+	return job, nil
+}
+
+// path returns the path of the results file of a job, IDs are checked so that they
+// can't point outside of the directory.
+func (q *JobQueue) path(id string) string {
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		id = "invalid"
+	}
+	return filepath.Join(q.options.Dir, id+".json")
+}
+
+// finish sets the finishing time of the job and stores it, forgetting it only then so
+// that it can always be found. If it can't be stored the error is logged and the job
+// is kept in memory. It returns the job stored, the lock must not be held.
+func (q *JobQueue) finish(j *queuedJob) Job {
+	q.mu.Lock()
+	if j.Finished == nil {
+		now := time.Now()
+		j.Finished = &now
+	}
+	job := j.Job
+	q.mu.Unlock()
+
+	if err := q.store(job); err != nil {
+		q.options.ErrorLog.Printf("Storing job %s: %v", job.ID, err)
+		return job
+	}
+	q.mu.Lock()
+	delete(q.jobs, job.ID)
+	q.mu.Unlock()
+	return job
+}
+
+// store writes the job to the results directory, through a temporary file so that
+// readers never see it half written.
+func (q *JobQueue) store(job Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := q.path(job.ID) + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path(job.ID))
+}
+
+func (q *JobQueue) work() {
+	defer q.workers.Done()
+	for q.ctx.Err() == nil {
+		if !q.run() {
+			select {
+			case <-q.ctx.Done():
+			case <-q.ready:
+			}
+		}
+	}
+}
+
+// run solves the puzzle of the oldest job waiting and stores the result. It returns
+// false if no job is waiting.
+func (q *JobQueue) run() bool {
+	q.mu.Lock()
+	if len(q.waiting) == 0 {
+		q.mu.Unlock()
+		return false
+	}
+	j := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	if len(q.waiting) > 0 {
+		// let another worker take the next one
+		q.wake()
+	}
+	ctx, cancel := context.WithTimeout(q.ctx, q.options.Timeout)
+	defer cancel()
+	now := time.Now()
+	j.Status, j.Started, j.cancel = JobRunning, &now, cancel
+	q.mu.Unlock()
+
+	t := NewTreeSolver(j.puzzle)
+	board, ok := t.SolveContext(ctx)
+	stats := t.Stats()
+
+	q.mu.Lock()
+	j.Stats = &stats
+	switch {
+	case j.Status == JobCancelled:
+	case q.ctx.Err() != nil:
+		// the queue was closed
+		j.Status = JobCancelled
+	case ctx.Err() != nil:
+		j.Status, j.Error = JobFailed, errTimeout()
+	case !ok:
+		j.Status, j.Error = JobFailed, errUnsolvable()
+	default:
+		j.Status, j.Board = JobDone, boardStrings(board)
+	}
+	q.mu.Unlock()
+	q.finish(j)
+	return true
+}
+
+// submitJobs queues the puzzle in the body, or each of its "puzzles" as in the puzzle
+// files, returning the jobs created.
+func (s *Server) submitJobs(ctx context.Context, r *http.Request) (interface{}, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	var batch struct {
+		Puzzles []json.RawMessage
+	}
+	if err = json.Unmarshal(body, &batch); err != nil {
+		return nil, errBadRequest("Invalid puzzle: " + err.Error())
+	}
+
+	var puzzles []Puzzle
+	if batch.Puzzles == nil {
+		p, err := s.decodePuzzle(body, nil)
+		if err != nil {
+			return nil, err
+		}
+		puzzles = append(puzzles, p)
+	}
+	for i, data := range batch.Puzzles {
+		p, err := s.decodePuzzle(data, nil)
+		if err != nil {
+			e := err.(*apiError)
+			return nil, &apiError{e.Status, e.Code, fmt.Sprintf("Puzzle %d: %s", i+1, e.Message)}
+		}
+		puzzles = append(puzzles, p)
+	}
+
+	jobs, err := s.options.Jobs.Submit(puzzles...)
+	switch {
+	case err == ErrQueueFull:
+		return nil, &apiError{http.StatusServiceUnavailable, "queue_full", err.Error()}
+	case err == ErrQueueClosed:
+		return nil, &apiError{http.StatusServiceUnavailable, "queue_closed", err.Error()}
+	case err != nil:
+		return nil, err
+	}
+	return map[string]interface{}{"jobs": jobs}, nil
+}
+
+// job returns the job with the ID in the path, or cancels it with DELETE.
+func (s *Server) job(ctx context.Context, r *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	switch r.Method {
+	case "GET":
+		return s.options.Jobs.Get(id)
+	case "DELETE":
+		return s.options.Jobs.Cancel(id)
+	}
+	return nil, &apiError{http.StatusMethodNotAllowed, "method_not_allowed", "Use GET or DELETE for /jobs/"}
+}
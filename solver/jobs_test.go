@@ -0,0 +1,242 @@
+package solver
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitJob polls the queue until the job is finished.
+func waitJob(t *testing.T, q *JobQueue, id string) Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := q.Get(id)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if job.Finished != nil {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("Job %s still not finished", id)
+	t.FailNow()
+	return Job{}
+}
+
+func TestJobQueue(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	smiley, _ := inputFile.GetByName("smiley")
+	heart, _ := inputFile.GetByName("heart")
+	dir := t.TempDir()
+
+	q, err := NewJobQueue(JobOptions{Dir: dir, Workers: 2})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	jobs, err := q.Submit(smiley, heart, Puzzle{Rows: [][]int{{1}}, Cols: [][]int{{0}}})
+	if err != nil || len(jobs) != 3 || jobs[0].Status != JobQueued || jobs[0].ID == jobs[1].ID {
+		t.Errorf("Unexpected jobs %+v, error %v", jobs, err)
+		t.FailNow()
+	}
+
+	job := waitJob(t, q, jobs[0].ID)
+	if job.Status != JobDone || strings.Join(job.Board, "/") != "x###x/#x#x#/#####/#xxx#/x###x" || job.PuzzleHash != PuzzleHash(smiley) {
+		t.Errorf("Unexpected job %+v", job)
+		t.FailNow()
+	}
+	if job = waitJob(t, q, jobs[1].ID); job.Status != JobDone || job.Name != "heart" {
+		t.Errorf("Unexpected job %+v", job)
+		t.FailNow()
+	}
+	if job = waitJob(t, q, jobs[2].ID); job.Status != JobFailed || job.Error.Code != "unsolvable" {
+		t.Errorf("Expected the job to fail, got %+v", job)
+		t.FailNow()
+	}
+	q.Close()
+
+	// finished jobs are still found after a restart
+	if _, err = os.Stat(filepath.Join(dir, jobs[0].ID+".json")); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	q, _ = NewJobQueue(JobOptions{Dir: dir})
+	defer q.Close()
+	if job, err = q.Get(jobs[0].ID); err != nil || job.Status != JobDone || len(job.Board) != 5 {
+		t.Errorf("Unexpected job %+v, error %v", job, err)
+		t.FailNow()
+	}
+	if _, err = q.Get("../" + jobs[0].ID); err == nil {
+		t.Errorf("Expected IDs outside of the directory to be refused")
+		t.FailNow()
+	}
+}
+
+func TestJobQueueCancel(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	forever, _ := inputFile.GetByName("forever")
+	smiley, _ := inputFile.GetByName("smiley")
+
+	q, _ := NewJobQueue(JobOptions{Dir: t.TempDir(), Workers: 1, MaxQueued: 2})
+	defer q.Close()
+	jobs, _ := q.Submit(forever, smiley)
+
+	// wait for the first job to run, leaving the second one in the queue
+	for job, _ := q.Get(jobs[0].ID); job.Status != JobRunning; job, _ = q.Get(jobs[0].ID) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := q.Submit(smiley, smiley); err != ErrQueueFull {
+		t.Errorf("Expected the queue to be full, got %v", err)
+		t.FailNow()
+	}
+
+	if job, err := q.Cancel(jobs[1].ID); err != nil || job.Status != JobCancelled {
+		t.Errorf("Unexpected job %+v, error %v", job, err)
+		t.FailNow()
+	}
+	if _, err := q.Submit(smiley, smiley); err != nil {
+		t.Errorf("Expected the cancelled job to leave room in the queue, got %v", err)
+		t.FailNow()
+	}
+	q.Cancel(jobs[0].ID)
+	if job := waitJob(t, q, jobs[0].ID); job.Status != JobCancelled || job.Started == nil {
+		t.Errorf("Expected the job to be cancelled, got %+v", job)
+		t.FailNow()
+	}
+	if job := waitJob(t, q, jobs[1].ID); job.Status != JobCancelled || job.Started != nil {
+		t.Errorf("Expected the job to be cancelled before running, got %+v", job)
+		t.FailNow()
+	}
+
+	q, _ = NewJobQueue(JobOptions{Dir: t.TempDir(), Timeout: 10 * time.Millisecond})
+	defer q.Close()
+	jobs, _ = q.Submit(forever)
+	if job := waitJob(t, q, jobs[0].ID); job.Status != JobFailed || job.Error.Code != "timeout" {
+		t.Errorf("Expected the job to time out, got %+v", job)
+		t.FailNow()
+	}
+}
+
+func TestServerJobs(t *testing.T) {
+	q, _ := NewJobQueue(JobOptions{Dir: t.TempDir()})
+	defer q.Close()
+	s := NewServer(JSONObject{}, ServerOptions{Jobs: q})
+
+	var submitted struct{ Jobs []Job }
+	request(t, s, "POST", "/jobs", smileyJSON, http.StatusOK, &submitted)
+	if len(submitted.Jobs) != 1 || submitted.Jobs[0].Name != "smiley" {
+		t.Errorf("Unexpected jobs %+v", submitted)
+		t.FailNow()
+	}
+	id := submitted.Jobs[0].ID
+	waitJob(t, q, id)
+
+	var job Job
+	request(t, s, "GET", "/jobs/"+id, "", http.StatusOK, &job)
+	if job.Status != JobDone || len(job.Board) != 5 {
+		t.Errorf("Unexpected job %+v", job)
+		t.FailNow()
+	}
+	request(t, s, "DELETE", "/jobs/"+id, "", http.StatusOK, &job)
+	if job.Status != JobDone {
+		t.Errorf("Expected finished jobs to be left alone, got %+v", job)
+		t.FailNow()
+	}
+
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	data, _ := json.Marshal(inputFile)
+	request(t, s, "POST", "/jobs", string(data), http.StatusOK, &submitted)
+	if len(submitted.Jobs) != len(inputFile.Puzzles) || submitted.Jobs[1].Name != inputFile.Puzzles[1].Name {
+		t.Errorf("Expected a job for each puzzle, got %+v", submitted)
+		t.FailNow()
+	}
+
+	var e struct{ Error apiError }
+	request(t, s, "GET", "/jobs/0123", "", http.StatusNotFound, &e)
+	request(t, s, "PUT", "/jobs/"+id, "", http.StatusMethodNotAllowed, &e)
+	request(t, s, "POST", "/jobs", `{"puzzles": [{"rows": [[1]]}]}`, http.StatusBadRequest, &e)
+	request(t, s, "POST", "/jobs", `{"rows": [["a"]], "cols": [[1]]}`, http.StatusBadRequest, &e)
+	if !strings.Contains(e.Error.Message, `"a"`) {
+		t.Errorf("Expected the invalid clue to be reported, got %+v", e)
+		t.FailNow()
+	}
+	request(t, s, "POST", "/jobs", `{"puzzles": [`+smileyJSON+`, {"rows": [[1.5]], "cols": [[1]]}]}`, http.StatusBadRequest, &e)
+	if !strings.HasPrefix(e.Error.Message, "Puzzle 2: Invalid puzzle") {
+		t.Errorf("Expected the invalid puzzle to be reported, got %+v", e)
+		t.FailNow()
+	}
+
+	s = NewServer(JSONObject{}, ServerOptions{Jobs: q, MaxCells: 25})
+	request(t, s, "POST", "/jobs", `{"puzzles": [`+smileyJSON+`, {"rows": [[1],[1],[1],[1],[1],[1]], "cols": [[6],[0],[0],[0],[0]]}]}`, http.StatusRequestEntityTooLarge, &e)
+	if e.Error.Code != "too_large" || !strings.HasPrefix(e.Error.Message, "Puzzle 2") {
+		t.Errorf("Unexpected error %+v", e)
+		t.FailNow()
+	}
+
+	// without a queue there are no jobs
+	s = NewServer(JSONObject{}, ServerOptions{})
+	request(t, s, "POST", "/jobs", smileyJSON, http.StatusNotFound, &e)
+}
+
+func TestJobQueueClose(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	forever, _ := inputFile.GetByName("forever")
+	smiley, _ := inputFile.GetByName("smiley")
+	dir := t.TempDir()
+
+	q, _ := NewJobQueue(JobOptions{Dir: dir, Workers: 1})
+	jobs, _ := q.Submit(forever, smiley)
+	for job, _ := q.Get(jobs[0].ID); job.Status != JobRunning; job, _ = q.Get(jobs[0].ID) {
+		time.Sleep(time.Millisecond)
+	}
+	q.Close()
+	if _, err := q.Submit(smiley); err != ErrQueueClosed {
+		t.Errorf("Expected the queue to be closed, got %v", err)
+		t.FailNow()
+	}
+
+	// both the running job and the waiting one are stored as cancelled
+	q, _ = NewJobQueue(JobOptions{Dir: dir})
+	defer q.Close()
+	for _, job := range jobs {
+		if job, err := q.Get(job.ID); err != nil || job.Status != JobCancelled || job.Finished == nil {
+			t.Errorf("Unexpected job %+v, error %v", job, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestJobQueueStoreError(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	smiley, _ := inputFile.GetByName("smiley")
+	dir := filepath.Join(t.TempDir(), "results")
+	var errors bytes.Buffer
+
+	q, _ := NewJobQueue(JobOptions{Dir: dir, ErrorLog: log.New(&errors, "", 0)})
+	os.Remove(dir)
+	jobs, _ := q.Submit(smiley)
+
+	// the job can't be stored but it's kept in memory
+	if job := waitJob(t, q, jobs[0].ID); job.Status != JobDone || !strings.Contains(errors.String(), jobs[0].ID) {
+		t.Errorf("Unexpected job %+v, errors %q", job, errors.String())
+		t.FailNow()
+	}
+
+	// and it's stored when closing the queue
+	os.Mkdir(dir, 0755)
+	q.Close()
+	q, _ = NewJobQueue(JobOptions{Dir: dir})
+	defer q.Close()
+	if job, err := q.Get(jobs[0].ID); err != nil || job.Status != JobDone {
+		t.Errorf("Unexpected job %+v, error %v", job, err)
+		t.FailNow()
+	}
+}
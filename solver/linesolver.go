@@ -51,25 +51,27 @@ const (
 // is impossible (a contradiction is found).
 // This usually means that the solver made a wrong guess at some point
 // and backtracking will be needed for solving the puzzle.
+//
+// Constraints containing Unknown block lengths (or no constraints at all)
+// are handled by reachLine, which considers all the consistent clue sequences.
 func SolveLine(constraints []int, line []Cell) (result []Cell, ok bool) {
+	if hasUnknown(constraints) {
+		result, ok = reachLine(constraints, line)
+		return
+	}
 	result, ok = intersect(constraints, line)
 	return
 }
 
 func intersect(constraints []int, line []Cell) (result []Cell, ok bool) {
 	result = make([]Cell, len(line))
+	copy(result, line)
 	ok = true
 	// if the line is completely empty or full the solution is trivial
 	if constraints[0] == 0 {
-		for i := range result {
-			result[i] = marked
-		}
-		return
+		return fillLine(line, marked)
 	} else if constraints[0] == len(line) {
-		for i := range result {
-			result[i] = full
-		}
-		return
+		return fillLine(line, full)
 	}
 
 	changed, rb, lb := 0, 0, 0
@@ -121,6 +123,20 @@ func intersect(constraints []int, line []Cell) (result []Cell, ok bool) {
 	return
 }
 
+// fillLine returns a line with all cells set to value, failing if any cell
+// already holds a different value.
+func fillLine(line []Cell, value Cell) (result []Cell, ok bool) {
+	result = make([]Cell, len(line))
+	for i, cell := range line {
+		if cell != empty && cell != value {
+			return
+		}
+		result[i] = value
+	}
+	ok = true
+	return
+}
+
 func newLeftLineSolver(constraints []int, line []Cell) FastLineSolver {
 	return FastLineSolver{
 		currentIndex:  0,
@@ -308,9 +324,9 @@ func (ls *FastLineSolver) backtrack() {
 }
 
 func (ls *FastLineSolver) advanceBlock() {
-	for ls.coverage[ls.blockIndex] < 0 && ls.isPositionOfBlockNotCovered() {
+	for ls.coverage[ls.blockIndex] < 0 || ls.isPositionOfBlockNotCovered() {
 		if ls.line[ls.currentIndex] == marked {
-			if ls.coverage[ls.blockIndex] > 0 {
+			if ls.coverage[ls.blockIndex] >= 0 {
 				ls.state = backtrack
 			} else {
 				ls.positions[ls.blockIndex] = ls.nextIndex()
@@ -326,7 +342,7 @@ func (ls *FastLineSolver) advanceBlock() {
 			ls.currentIndex = ls.nextIndex()
 
 			if ls.coverage[ls.blockIndex] == -1 {
-				ls.coverage[ls.blockIndex] = ls.currentIndex - 1
+				ls.coverage[ls.blockIndex] = ls.previousIndex()
 			}
 			ls.state = finalspace
 			return
@@ -0,0 +1,107 @@
+package solver
+
+// Row and Column identify the type of the lines reported to an Observer.
+const (
+	Row    = row
+	Column = column
+)
+
+// An Observer is notified by a TreeSolver as it makes progress, e.g. to animate
+// the solving or to log it.
+//
+// Every method receives a snapshot of the board taken when the event happened, which
+// is shared by all the observers and must not be modified. Observers are called on the
+// goroutine running the search and they slow it down, so they should return quickly.
+type Observer interface {
+	// CellsChanged is called when the line solver fills in some cells of a line,
+	// given by their index in the line, right before LineSolved is called for it
+	CellsChanged(lt LineType, index int, cells []int, board Board)
+	// LineSolved is called every time the line solver runs on a line without
+	// finding a contradiction
+	LineSolved(lt LineType, index int, board Board)
+	// Guess is called when the search sets the cell at row r and column c to value,
+	// depth is the number of guesses the board depends on including this one
+	Guess(r int, c int, value Cell, depth int, board Board)
+	// Backtrack is called when the search undoes the guess at row r and column c,
+	// going back to the board before it
+	Backtrack(r int, c int, depth int, board Board)
+	// SolutionFound is called with every solution found
+	SolutionFound(board Board)
+}
+
+// BaseObserver implements all the methods of Observer doing nothing, it can be embedded
+// by observers interested only in some of the events.
+type BaseObserver struct{}
+
+// CellsChanged implements Observer
+func (BaseObserver) CellsChanged(lt LineType, index int, cells []int, board Board) {}
+
+// LineSolved implements Observer
+func (BaseObserver) LineSolved(lt LineType, index int, board Board) {}
+
+// Guess implements Observer
+func (BaseObserver) Guess(r int, c int, value Cell, depth int, board Board) {}
+
+// Backtrack implements Observer
+func (BaseObserver) Backtrack(r int, c int, depth int, board Board) {}
+
+// SolutionFound implements Observer
+func (BaseObserver) SolutionFound(board Board) {}
+
+// WithObserver subscribes an observer to the progress of the solver, it can be given
+// more than once to subscribe several observers.
+//
+// A ParallelSearch only notifies the solutions found, since the other events would
+// come from several goroutines at once.
+func WithObserver(o Observer) Option {
+	return func(t *TreeSolver) {
+		t.observers = append(t.observers, o)
+	}
+}
+
+// notifyLine notifies the observers that a line was solved, changing the given cells.
+func (t *TreeSolver) notifyLine(lt LineType, index int, changed bitset) {
+	if len(t.observers) == 0 {
+		return
+	}
+	board := t.board.toBoard()
+
+	if changed != nil {
+		cells := make([]int, 0, changed.count())
+		for i := changed.next(0); i >= 0; i = changed.next(i + 1) {
+			cells = append(cells, i)
+		}
+		for _, o := range t.observers {
+			o.CellsChanged(lt, index, cells, board)
+		}
+	}
+	for _, o := range t.observers {
+		o.LineSolved(lt, index, board)
+	}
+}
+
+func (t *TreeSolver) notifyGuess(r int, c int, value Cell) {
+	if len(t.observers) == 0 {
+		return
+	}
+	board := t.board.toBoard()
+	for _, o := range t.observers {
+		o.Guess(r, c, value, t.depth, board)
+	}
+}
+
+func (t *TreeSolver) notifyBacktrack(r int, c int) {
+	if len(t.observers) == 0 {
+		return
+	}
+	board := t.board.toBoard()
+	for _, o := range t.observers {
+		o.Backtrack(r, c, t.depth, board)
+	}
+}
+
+func (t *TreeSolver) notifySolution(board Board) {
+	for _, o := range t.observers {
+		o.SolutionFound(board)
+	}
+}
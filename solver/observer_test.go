@@ -0,0 +1,94 @@
+package solver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type recordingObserver struct {
+	lines, changes, cells, guesses, backtracks int
+	solutions                                  []Board
+	t                                          *testing.T
+}
+
+func (o *recordingObserver) CellsChanged(lt LineType, index int, cells []int, board Board) {
+	o.changes++
+	o.cells += len(cells)
+	for _, i := range cells {
+		r, c := index, i
+		if lt == Column {
+			r, c = i, index
+		}
+		if board[r][c] == empty {
+			o.t.Errorf("Changed cell (%d, %d) is empty in the snapshot", r, c)
+		}
+	}
+}
+
+func (o *recordingObserver) LineSolved(lt LineType, index int, board Board) {
+	o.lines++
+}
+
+func (o *recordingObserver) Guess(r int, c int, value Cell, depth int, board Board) {
+	o.guesses++
+	if board[r][c] != value || depth <= 0 {
+		o.t.Errorf("Guess of %v at (%d, %d) with depth %d not in the snapshot", value, r, c, depth)
+	}
+}
+
+func (o *recordingObserver) Backtrack(r int, c int, depth int, board Board) {
+	o.backtracks++
+	if board[r][c] != empty {
+		o.t.Errorf("Backtrack at (%d, %d) didn't restore the cell", r, c)
+	}
+}
+
+func (o *recordingObserver) SolutionFound(board Board) {
+	o.solutions = append(o.solutions, board)
+}
+
+func TestObserver(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("edge")
+
+	o := &recordingObserver{t: t}
+	s := NewTreeSolver(puz, WithObserver(o))
+	board := s.Solve()
+	stats := s.Stats()
+
+	if o.lines != stats.LineSolves-stats.Contradictions || o.changes != stats.LineChanges || o.cells != stats.CellChanges {
+		t.Errorf("Line events don't match the stats: %+v, %+v", o, stats)
+		t.FailNow()
+	}
+	if o.guesses != stats.Guesses || o.backtracks != stats.Backtracks {
+		t.Errorf("Search events don't match the stats: %+v, %+v", o, stats)
+		t.FailNow()
+	}
+	if len(o.solutions) != 1 || !reflect.DeepEqual(o.solutions[0], board) {
+		t.Errorf("Expected the solution to be notified, got %v", o.solutions)
+		t.FailNow()
+	}
+}
+
+type solutionCounter struct {
+	BaseObserver
+	count int
+}
+
+func (o *solutionCounter) SolutionFound(board Board) {
+	o.count++
+}
+
+func TestBaseObserver(t *testing.T) {
+	puz := Puzzle{Name: "diagonals", Rows: [][]int{{1}, {1}}, Cols: [][]int{{1}, {1}}}
+	o := &solutionCounter{}
+
+	for range NewTreeSolver(puz, WithObserver(o)).Solutions(context.Background()) {
+	}
+
+	if o.count != 2 {
+		t.Errorf("Expected 2 solutions, got %d", o.count)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,319 @@
+package solver
+
+import (
+	"container/heap"
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// WithWorkers makes the solver use the parallel logic mode, solving the rows (then the
+// columns) that need a new pass of the line solver on n goroutines at once.
+// If n is zero or less one goroutine per CPU is used, with n equal to 1 lines are solved
+// one at a time in order of priority.
+func WithWorkers(n int) Option {
+	return func(t *TreeSolver) {
+		if n <= 0 {
+			n = runtime.NumCPU()
+		}
+		t.workers = n
+	}
+}
+
+// parallelLogicSolve is the counterpart of logicSolve for the parallel logic mode.
+//
+// Rows are independent of each other, as are columns: it takes all the queued rows and
+// solves them concurrently on the same board, then merges the new lines in index order,
+// queueing the columns crossing the changed cells. Then it does the same for columns,
+// alternating until no more lines are queued. Since every pass starts from the same board
+// and is merged in a fixed order, the result doesn't depend on how goroutines are scheduled.
+func (t *TreeSolver) parallelLogicSolve() (emptyCells int, ok bool) {
+	if len(t.jobs) == 0 {
+		t.initJobs()
+	}
+
+	for lt := row; len(t.jobs) > 0; lt = 1 - lt {
+		jobs := t.takeJobs(lt)
+		results := make([]bitLine, len(jobs))
+		solved := make([]bool, len(jobs))
+		hits := make([]bool, len(jobs))
+
+		runParallel(len(jobs), t.workers, func(i int) {
+			results[i], solved[i], hits[i] = t.solveLine(jobs[i].constraints, t.board.line(lt, jobs[i].index))
+		})
+
+		for i, job := range jobs {
+			if !solved[i] {
+				//contradiction, stops solving
+				t.stats.lineSolved(nil, hits[i], t.cache != nil)
+				return
+			}
+
+			var changed bitset
+			line := t.board.line(lt, job.index)
+			if !line.equal(results[i]) {
+				changed = line.changes(results[i])
+				t.board.setLine(lt, job.index, results[i], changed)
+				t.updateJobs(job, changed)
+			}
+			t.stats.lineSolved(changed, hits[i], t.cache != nil)
+			t.notifyLine(lt, job.index, changed)
+		}
+	}
+
+	ok = true
+	emptyCells = t.emptyCells()
+	return
+}
+
+// takeJobs removes from the queue all the jobs for lines of the given type, sorted by index.
+func (t *TreeSolver) takeJobs(lt LineType) []*treeSolverJob {
+	jobs := make([]*treeSolverJob, 0)
+
+	for index, job := range t.queued[lt] {
+		if job != nil {
+			heap.Remove(&t.jobs, job.heapIndex)
+			t.queued[lt][index] = nil
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// runParallel calls f for every index in [0, n) using at most the given number of goroutines,
+// it returns once all the calls are done.
+func runParallel(n int, workers int, f func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	next := int64(-1)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+				f(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// WorkerStats reports the work done by one of the goroutines of a parallel search.
+type WorkerStats struct {
+	// Nodes is the number of branches taken from the shared frontier
+	Nodes int
+	// Steps is the number of times the line solver ran to a fixpoint
+	Steps int
+	// Guesses is the number of cells guessed, each one pushing a branch to the frontier
+	Guesses        int
+	Contradictions int
+	Solutions      int
+}
+
+// SearchResult is the outcome of a parallel search.
+type SearchResult struct {
+	Solutions []Board
+	Workers   []WorkerStats
+}
+
+// searchNode is a branch of the search tree waiting in the frontier: a board where
+// the cell at (r, c) has just been guessed, or the root of the tree if r is -1.
+// The depth is the number of guesses made to reach it.
+type searchNode struct {
+	board bitBoard
+	r, c  int
+	depth int
+}
+
+// searchFrontier is the stack of branches shared by the goroutines of a parallel search,
+// it's closed once it's empty and no goroutine is working on a branch that could add
+// new ones, or when the search is stopped.
+type searchFrontier struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	nodes  []searchNode
+	busy   int
+	closed bool
+}
+
+func newSearchFrontier(root searchNode) *searchFrontier {
+	f := &searchFrontier{nodes: []searchNode{root}}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// pop waits for a branch to explore, it returns false once the frontier is closed.
+func (f *searchFrontier) pop() (node searchNode, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for len(f.nodes) == 0 && f.busy > 0 && !f.closed {
+		f.cond.Wait()
+	}
+	if f.closed || len(f.nodes) == 0 {
+		f.closed = true
+		f.cond.Broadcast()
+		return
+	}
+
+	node, ok = f.nodes[len(f.nodes)-1], true
+	f.nodes = f.nodes[:len(f.nodes)-1]
+	f.busy++
+	return
+}
+
+func (f *searchFrontier) push(node searchNode) {
+	f.mu.Lock()
+	f.nodes = append(f.nodes, node)
+	f.cond.Signal()
+	f.mu.Unlock()
+}
+
+// done marks the end of the work on a branch taken with pop.
+func (f *searchFrontier) done() {
+	f.mu.Lock()
+	f.busy--
+	if f.busy == 0 && len(f.nodes) == 0 {
+		f.cond.Broadcast()
+	}
+	f.mu.Unlock()
+}
+
+func (f *searchFrontier) close() {
+	f.mu.Lock()
+	f.closed = true
+	f.cond.Broadcast()
+	f.mu.Unlock()
+}
+
+// ParallelSearch explores the search tree on the given number of goroutines (one per CPU
+// if workers is zero or less), returning the solutions found and what each goroutine did.
+//
+// Whenever a goroutine guesses a cell it keeps exploring the full branch and pushes the
+// marked one to a frontier shared with the other goroutines, which take the most recently
+// pushed branch when they run out of work.
+// The search stops after limit solutions, or when ctx is cancelled: a limit of 1 finds any
+// solution, a limit of 2 is enough to tell if the solution is unique and a limit of zero or
+// less explores the whole tree. Solutions are not returned in any particular order.
+func (t *TreeSolver) ParallelSearch(ctx context.Context, workers int, limit int) (result SearchResult) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	defer t.timeSearch()()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frontier := newSearchFrontier(searchNode{board: t.board.clone(), r: -1})
+	go func() {
+		<-ctx.Done()
+		frontier.close()
+	}()
+
+	var mu sync.Mutex
+	found := func(b Board) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if limit <= 0 || len(result.Solutions) < limit {
+			result.Solutions = append(result.Solutions, b)
+			t.notifySolution(b)
+		}
+		if limit > 0 && len(result.Solutions) >= limit {
+			cancel()
+		}
+	}
+
+	result.Workers = make([]WorkerStats, workers)
+	solverStats := make([]Stats, workers)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(stats *WorkerStats, solverStats *Stats) {
+			defer wg.Done()
+
+			for node, ok := frontier.pop(); ok; node, ok = frontier.pop() {
+				stats.Nodes++
+				f := t.fork(node)
+				f.explore(ctx, frontier, stats, found)
+				solverStats.add(f.stats)
+				frontier.done()
+			}
+		}(&result.Workers[w], &solverStats[w])
+	}
+	wg.Wait()
+
+	for w, stats := range result.Workers {
+		t.GuessCount += stats.Guesses
+		t.stats.add(solverStats[w])
+	}
+	return
+}
+
+// fork creates a solver with the same puzzle and options as t, starting from a branch
+// of the search tree.
+func (t *TreeSolver) fork(node searchNode) *TreeSolver {
+	f := &TreeSolver{puzzle: t.puzzle, board: node.board, cache: t.cache, lineSolver: t.lineSolver, workers: t.workers, depth: node.depth}
+	f.queued[row] = make([]*treeSolverJob, len(t.puzzle.Rows))
+	f.queued[column] = make([]*treeSolverJob, len(t.puzzle.Cols))
+
+	f.stats.MaxDepth = node.depth
+	if node.r < 0 {
+		f.initJobs()
+	} else {
+		// the node is the other value of a guess, trying it is a backtrack
+		f.stats.Backtracks++
+		f.addJob(row, node.r, 1)
+		f.addJob(column, node.c, 1)
+	}
+	return f
+}
+
+// explore follows a branch of the search tree depth-first, always guessing full cells
+// and pushing the alternatives to the frontier, until it's solved or a contradiction
+// is found.
+func (t *TreeSolver) explore(ctx context.Context, frontier *searchFrontier, stats *WorkerStats, found func(Board)) {
+	for ctx.Err() == nil {
+		stats.Steps++
+		emptyCells, ok := t.logicSolve()
+
+		if !ok {
+			stats.Contradictions++
+			t.stats.Contradictions++
+			return
+		}
+		if emptyCells == 0 {
+			stats.Solutions++
+			found(t.board.toBoard())
+			return
+		}
+
+		r, c := t.pickCell()
+		stats.Guesses++
+		t.depth++
+		if t.depth > t.stats.MaxDepth {
+			t.stats.MaxDepth = t.depth
+		}
+
+		alternative := t.board.clone()
+		alternative.set(r, c, marked)
+		frontier.push(searchNode{alternative, r, c, t.depth})
+
+		t.board.set(r, c, full)
+		t.clearJobs()
+		t.addJob(row, r, 1)
+		t.addJob(column, c, 1)
+	}
+}
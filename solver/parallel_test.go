@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelLogicSolve(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		if puz.Name == "forever" {
+			continue
+		}
+		expected := NewTreeSolver(puz).Solve()
+
+		for _, workers := range []int{2, 3, 8} {
+			board := NewTreeSolver(puz, WithWorkers(workers)).Solve()
+
+			if !reflect.DeepEqual(board, expected) {
+				t.Errorf("Puzzle %s with %d workers: expected\n%v, got\n%v", puz.Name, workers, expected, board)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	for _, workers := range []int{1, 4, 100} {
+		var sum int64
+		runParallel(50, workers, func(i int) {
+			atomic.AddInt64(&sum, int64(i))
+		})
+
+		if sum != 50*49/2 {
+			t.Errorf("Expected %d, got %d with %d workers", 50*49/2, sum, workers)
+			t.FailNow()
+		}
+	}
+}
+
+func TestParallelSearch(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("20x20")
+	expected := NewTreeSolver(puz).Solve()
+
+	result := NewTreeSolver(puz).ParallelSearch(context.Background(), 4, 2)
+
+	if len(result.Solutions) != 1 || !reflect.DeepEqual(result.Solutions[0], expected) {
+		t.Errorf("Expected a single solution\n%v, got %v", expected, result.Solutions)
+		t.FailNow()
+	}
+	if len(result.Workers) != 4 {
+		t.Errorf("Expected stats for 4 workers, got %v", result.Workers)
+		t.FailNow()
+	}
+
+	nodes := 0
+	for _, stats := range result.Workers {
+		nodes += stats.Nodes
+	}
+	if nodes == 0 {
+		t.Errorf("Expected at least one node to be explored")
+		t.FailNow()
+	}
+
+	// a 2x2 puzzle with two solutions: the two diagonals
+	puz = Puzzle{Name: "diagonals", Rows: [][]int{{1}, {1}}, Cols: [][]int{{1}, {1}}}
+
+	for _, limit := range []int{0, 1, 2} {
+		result = NewTreeSolver(puz).ParallelSearch(context.Background(), 3, limit)
+
+		expectedCount := limit
+		if limit == 0 {
+			expectedCount = 2
+		}
+		if len(result.Solutions) != expectedCount {
+			t.Errorf("Expected %d solutions with limit %d, got %d", expectedCount, limit, len(result.Solutions))
+			t.FailNow()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if result = NewTreeSolver(puz).ParallelSearch(ctx, 3, 0); len(result.Solutions) != 0 {
+		t.Errorf("Expected no solutions after cancel, got %d", len(result.Solutions))
+	}
+}
@@ -0,0 +1,176 @@
+package solver
+
+// Unknown is used in the constraints of a line in place of a block length that is not known,
+// e.g. [3, Unknown, 2] describes a line with three blocks where the length of the second one
+// could not be read. A line with no constraints at all (nil or empty) is fully unknown: any
+// number of blocks of any length can appear in it.
+const Unknown = -1
+
+// hasUnknown checks if the given constraints are not fully specified.
+func hasUnknown(constraints []int) bool {
+	if len(constraints) == 0 {
+		return true
+	}
+	for _, c := range constraints {
+		if c == Unknown {
+			return true
+		}
+	}
+	return false
+}
+
+// reachLine is a line solver for constraints that contain unknown block lengths.
+//
+// Instead of placing blocks to their leftmost and rightmost positions it considers the whole
+// set of clue sequences consistent with the constraints: for each block it computes which
+// placements can be extended to a full solution on both sides, then a cell is filled in only
+// if it is full (or empty) in every such solution.
+// The line is left untouched if it's fully unknown, since every line is consistent with it.
+func reachLine(constraints []int, line []Cell) (result []Cell, ok bool) {
+	result = make([]Cell, len(line))
+	copy(result, line)
+
+	if len(constraints) == 0 {
+		ok = true
+		return
+	}
+	if len(constraints) == 1 && constraints[0] == 0 {
+		return fillLine(line, marked)
+	}
+
+	n, m := len(line), len(constraints)
+	prefix := reachPrefix(constraints, line)
+	suffix := reachSuffix(constraints, line)
+
+	if !suffix[0][0] {
+		return
+	}
+	ok = true
+
+	canFull := make([]bool, n)
+	canEmpty := make([]bool, n)
+
+	for b := 0; b < m; b++ {
+		for s := 0; s < n; s++ {
+			if s > 0 && (line[s-1] == full || !prefix[b][s-1]) || s == 0 && b > 0 {
+				continue
+			}
+			for l := 1; s+l <= n; l++ {
+				if line[s+l-1] == marked {
+					break
+				}
+				if !blockFits(constraints[b], l) {
+					continue
+				}
+				end := s + l
+				if end == n && b != m-1 || end < n && (line[end] == full || !suffix[b+1][end+1]) {
+					continue
+				}
+				for i := s; i < end; i++ {
+					canFull[i] = true
+				}
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if line[i] == full {
+			continue
+		}
+		for b := 0; b <= m && !canEmpty[i]; b++ {
+			canEmpty[i] = prefix[b][i] && suffix[b][i+1]
+		}
+	}
+
+	for i := range result {
+		if canFull[i] && !canEmpty[i] {
+			result[i] = full
+		} else if canEmpty[i] && !canFull[i] {
+			result[i] = marked
+		}
+	}
+	return
+}
+
+// blockFits checks if a block of the given length satisfies a single constraint.
+func blockFits(constraint int, length int) bool {
+	return constraint == Unknown || constraint == length
+}
+
+// reachPrefix computes a table where prefix[b][i] is true if the first b blocks of the constraints
+// can be placed in the first i cells of the line.
+func reachPrefix(constraints []int, line []Cell) [][]bool {
+	n, m := len(line), len(constraints)
+	prefix := make([][]bool, m+1)
+	for b := range prefix {
+		prefix[b] = make([]bool, n+1)
+	}
+
+	prefix[0][0] = true
+	for i := 1; i <= n; i++ {
+		prefix[0][i] = prefix[0][i-1] && line[i-1] != full
+	}
+
+	for b := 1; b <= m; b++ {
+		for i := 1; i <= n; i++ {
+			if line[i-1] != full && prefix[b][i-1] {
+				prefix[b][i] = true
+				continue
+			}
+			// block b-1 ends exactly at cell i-1
+			for l := 1; l <= i; l++ {
+				s := i - l
+				if line[s] == marked {
+					break
+				}
+				if !blockFits(constraints[b-1], l) {
+					continue
+				}
+				if s == 0 && b == 1 || s > 0 && line[s-1] != full && prefix[b-1][s-1] {
+					prefix[b][i] = true
+					break
+				}
+			}
+		}
+	}
+	return prefix
+}
+
+// reachSuffix computes a table where suffix[b][i] is true if the blocks from b onwards can be
+// placed in the cells of the line starting at index i.
+func reachSuffix(constraints []int, line []Cell) [][]bool {
+	n, m := len(line), len(constraints)
+	suffix := make([][]bool, m+1)
+	for b := range suffix {
+		suffix[b] = make([]bool, n+1)
+	}
+
+	suffix[m][n] = true
+	for i := n - 1; i >= 0; i-- {
+		suffix[m][i] = suffix[m][i+1] && line[i] != full
+	}
+
+	for b := m - 1; b >= 0; b-- {
+		for i := n - 1; i >= 0; i-- {
+			if line[i] != full && suffix[b][i+1] {
+				suffix[b][i] = true
+				continue
+			}
+			// block b starts exactly at cell i
+			for l := 1; i+l <= n; l++ {
+				end := i + l
+				if line[end-1] == marked {
+					break
+				}
+				if !blockFits(constraints[b], l) {
+					continue
+				}
+				if end == n && b == m-1 || end < n && line[end] != full && suffix[b+1][end+1] {
+					suffix[b][i] = true
+					break
+				}
+			}
+		}
+	}
+	return suffix
+}
@@ -0,0 +1,142 @@
+package solver
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestReachLine(t *testing.T) {
+	line := []Cell{0, 0, 0, 0, 0}
+	constraints := []int{3}
+	expected := []Cell{0, 0, 1, 0, 0}
+
+	result, ok := reachLine(constraints, line)
+
+	if !ok || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+
+	line = []Cell{0, 0, 0, 0, 0}
+	constraints = []int{1, Unknown, 1}
+	expected = []Cell{1, 2, 1, 2, 1}
+
+	result, ok = reachLine(constraints, line)
+
+	if !ok || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+
+	line = []Cell{0, 0, 0, 1, 0, 0}
+	constraints = []int{Unknown, 2}
+	expected = []Cell{0, 0, 0, 1, 0, 2}
+
+	result, ok = reachLine(constraints, line)
+
+	if !ok || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+
+	line = []Cell{2, 1, 0, 0, 1, 1}
+	constraints = []int{Unknown, 2}
+	expected = []Cell{2, 1, 0, 2, 1, 1}
+
+	result, ok = reachLine(constraints, line)
+
+	if !ok || !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+		t.FailNow()
+	}
+
+	line = []Cell{1, 0, 2, 0, 1}
+	constraints = []int{Unknown}
+
+	_, ok = reachLine(constraints, line)
+
+	if ok {
+		t.Errorf("Expected a contradiction for %v with %v", line, constraints)
+		t.FailNow()
+	}
+
+	line = []Cell{1, 0, 2, 0, 0}
+	constraints = nil
+
+	result, ok = reachLine(constraints, line)
+
+	if !ok || !reflect.DeepEqual(result, line) {
+		t.Errorf("Expected %v, got %v", line, result)
+		t.FailNow()
+	}
+}
+
+// TestReachLineMatchesIntersect checks that on fully known constraints reachLine
+// never contradicts the fast line solver and finds at least the same cells.
+func TestReachLineMatchesIntersect(t *testing.T) {
+	constraints := []int{3, 3, 1, 4, 2}
+	line := []Cell{0, 1, 0, 0, 1, 0, 0, 2, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0}
+
+	fast, fastOk := intersect(constraints, line)
+	reach, reachOk := reachLine(constraints, line)
+
+	if fastOk != reachOk {
+		t.Errorf("Expected ok to be %v, got %v", fastOk, reachOk)
+		t.FailNow()
+	}
+
+	for i := range fast {
+		if fast[i] != empty && fast[i] != reach[i] {
+			t.Errorf("Cell %d: expected %v, got %v", i, fast[i], reach[i])
+			t.FailNow()
+		}
+	}
+}
+
+func TestDecodeUnknownClues(t *testing.T) {
+	data := []byte(`{"name": "hidden", "rows": [[3, null, 2], null, [1, "?"], [-1], [0]], "cols": [[1]]}`)
+	expected := [][]int{{3, Unknown, 2}, nil, {1, Unknown}, {Unknown}, {0}}
+
+	var p Puzzle
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if !reflect.DeepEqual(p.Rows, expected) {
+		t.Errorf("Expected %v, got %v", expected, p.Rows)
+		t.FailNow()
+	}
+
+	for _, clue := range []string{`"x"`, `-2`, `1.5`, `1e20`} {
+		data = []byte(`{"name": "broken", "rows": [[` + clue + `]], "cols": [[1]]}`)
+		if err := json.Unmarshal(data, &p); err == nil {
+			t.Errorf("Expected an error for the invalid clue %s", clue)
+			t.FailNow()
+		}
+	}
+
+	data = []byte(`{"rows": [[1], [1]], "cols": [[1], [1, 1e12]]}`)
+	if err := json.Unmarshal(data, &p); err == nil || err.Error() != "Invalid clue 1000000000000 in column 2" {
+		t.Errorf("Expected the clue and its line in the error, got %v", err)
+		t.FailNow()
+	}
+}
+
+func TestSolveUnknownClues(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, err := inputFile.GetByName("smiley-hidden")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	s := NewTreeSolver(puz)
+	board := s.Solve()
+
+	if s.emptyCells() != 0 || board[4][4] != marked || board[1][2] != full {
+		t.Errorf("Expected smiley to be solved, got\n%v", board)
+		t.FailNow()
+	}
+}
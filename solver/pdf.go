@@ -0,0 +1,174 @@
+package solver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The size of an A4 page in points.
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+)
+
+// pdfDocument is a minimal PDF writer: it supports pages drawn with rectangles, lines
+// and text in the standard Helvetica fonts, which PDF readers provide without embedding.
+//
+// Objects 1 to 4 are the catalog, the page tree and the regular and bold fonts, each
+// page adds its content stream and the page object itself.
+type pdfDocument struct {
+	objects [][]byte
+	pages   []int
+}
+
+func newPDFDocument() *pdfDocument {
+	d := &pdfDocument{}
+	d.add("") // catalog, written by write
+	d.add("") // page tree, written by write
+	d.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	d.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	return d
+}
+
+// add adds an object to the document, returning its number.
+func (d *pdfDocument) add(object string) int {
+	d.objects = append(d.objects, []byte(object))
+	return len(d.objects)
+}
+
+// addPage adds a page with the content drawn on the canvas.
+func (d *pdfDocument) addPage(c *pdfCanvas) {
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	zw.Write(c.content.Bytes())
+	zw.Close()
+
+	content := d.add(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	page := d.add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+		"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+		pdfPageWidth, pdfPageHeight, content))
+	d.pages = append(d.pages, page)
+}
+
+// write writes the document, followed by the cross-reference table giving the offset
+// of each object.
+func (d *pdfDocument) write(w io.Writer) error {
+	kids := make([]string, len(d.pages))
+	for i, page := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	d.objects[0] = []byte("<< /Type /Catalog /Pages 2 0 R >>")
+	d.objects[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	// the binary comment tells readers the file is not plain text
+	fmt.Fprint(cw, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(d.objects))
+	for i, object := range d.objects {
+		offsets[i] = cw.n
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, xref)
+
+	if cw.err != nil {
+		return cw.err
+	}
+	return bw.Flush()
+}
+
+// countingWriter counts the bytes written, keeping the first error.
+type countingWriter struct {
+	w   io.Writer
+	n   int
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += n
+	cw.err = err
+	return n, err
+}
+
+// pdfCanvas collects the drawing operators of a page. Coordinates are in points from
+// the top left corner of the page, unlike PDF which starts from the bottom left.
+type pdfCanvas struct {
+	content bytes.Buffer
+}
+
+func (c *pdfCanvas) fillRect(x float64, y float64, w float64, h float64, gray float64) {
+	fmt.Fprintf(&c.content, "%.3f g %.2f %.2f %.2f %.2f re f\n", gray, x, pdfPageHeight-y-h, w, h)
+}
+
+func (c *pdfCanvas) line(x1 float64, y1 float64, x2 float64, y2 float64, width float64) {
+	fmt.Fprintf(&c.content, "%.2f w 0 G %.2f %.2f m %.2f %.2f l S\n",
+		width, x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// text draws the text with its baseline at y, starting at x or centered on it.
+func (c *pdfCanvas) text(x float64, y float64, size float64, bold bool, centered bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	if centered {
+		x -= helveticaWidth(text, bold) * size / 2
+	}
+	fmt.Fprintf(&c.content, "0 g BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pdfPageHeight-y, pdfString(text))
+}
+
+// helveticaWidth returns the width of the text in Helvetica for a font size of 1.
+// Digits, ? and the space have fixed widths, other characters get an average one.
+func helveticaWidth(text string, bold bool) (width float64) {
+	for _, ch := range text {
+		switch {
+		case ch >= '0' && ch <= '9':
+			width += 0.556
+		case ch == '?':
+			width += 0.556
+			if bold {
+				width += 0.055
+			}
+		case ch == ' ':
+			width += 0.278
+		default:
+			width += 0.55
+		}
+	}
+	return
+}
+
+// pdfString escapes the text for a PDF string literal, characters outside of
+// Latin-1 are replaced by ?.
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, ch := range text {
+		switch {
+		case ch == '(' || ch == ')' || ch == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(ch)
+		case ch < 32 || ch > 255:
+			b.WriteByte('?')
+		case ch > 126:
+			fmt.Fprintf(&b, "\\%03o", ch)
+		default:
+			b.WriteRune(ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package solver
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// PNGOptions configures how boards are drawn by DrawBoard and WritePNG, the zero value
+// draws 10 pixel cells with clues and no marks.
+type PNGOptions struct {
+	// CellSize is the side of a cell in pixels, grid lines are left out below 4 pixels
+	// so that small thumbnails are just the picture
+	CellSize int
+	// Marks draws a × in the marked cells
+	Marks bool
+	// NoClues leaves out the clues, drawing only the grid
+	NoClues bool
+}
+
+// The colors of the images drawn by DrawBoard, in palette order.
+var pngPalette = color.Palette{
+	color.White,
+	color.Black,
+	color.Gray{Y: 0x80},
+	color.Gray{Y: 0xc8},
+}
+
+const (
+	pngWhite uint8 = iota
+	pngBlack
+	pngGray
+	pngLightGray
+)
+
+// glyphs is a 3x5 bitmap font for the clues, each row of a glyph is 3 bits with the
+// leftmost pixel in the highest one.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7},
+	'1': {2, 6, 2, 2, 7},
+	'2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7},
+	'4': {5, 5, 7, 1, 1},
+	'5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7},
+	'7': {7, 1, 1, 1, 1},
+	'8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'?': {7, 1, 3, 0, 2},
+}
+
+// DrawBoard draws the board as a paletted image, with the clues of the puzzle to the
+// left of the grid and above it unless options.NoClues is set. Full cells are black,
+// a nil board draws the blank puzzle.
+func DrawBoard(p Puzzle, board Board, options PNGOptions) *image.Paletted {
+	size := options.CellSize
+	if size <= 0 {
+		size = 10
+	}
+	rows, cols := len(p.Rows), len(p.Cols)
+	grid := size >= 4
+
+	// the clues are drawn in slots large enough for two digits of the font
+	pixel := max(1, size/8)
+	slot := max(size, 8*pixel)
+	left, top := 0, 0
+	if !options.NoClues {
+		for _, constraints := range p.Rows {
+			left = max(left, len(clueStrings(constraints)))
+		}
+		for _, constraints := range p.Cols {
+			top = max(top, len(clueStrings(constraints)))
+		}
+	}
+
+	x0, y0 := left*slot, top*slot
+	width, height := x0+cols*size, y0+rows*size
+	if grid {
+		// room for the last grid line
+		width++
+		height++
+	}
+	img := image.NewPaletted(image.Rect(0, 0, width, height), pngPalette)
+
+	if !options.NoClues {
+		for r, constraints := range p.Rows {
+			clues := clueStrings(constraints)
+			for i, clue := range clues {
+				drawText(img, clue, x0-(len(clues)-i)*slot+slot/2, y0+r*size+size/2, pixel)
+			}
+		}
+		for c, constraints := range p.Cols {
+			clues := clueStrings(constraints)
+			for i, clue := range clues {
+				drawText(img, clue, x0+c*size+size/2, y0-(len(clues)-i)*slot+slot/2, pixel)
+			}
+		}
+	}
+
+	for r := range board {
+		for c, cell := range board[r] {
+			x, y := x0+c*size, y0+r*size
+			switch {
+			case cell == full:
+				fillRect(img, x, y, x+size, y+size, pngBlack)
+			case cell == marked && options.Marks && size >= 5:
+				for d := size / 4; d < size-size/4; d++ {
+					img.SetColorIndex(x+d, y+d, pngGray)
+					img.SetColorIndex(x+size-1-d, y+d, pngGray)
+				}
+			}
+		}
+	}
+
+	if grid {
+		// thin lines first, so that the bold ones are drawn over them
+		for _, bold := range []bool{false, true} {
+			for r := 0; r <= rows; r++ {
+				if (r%5 == 0 || r == rows) == bold {
+					fillRect(img, x0, y0+r*size, x0+cols*size+1, y0+r*size+1, gridColor(bold))
+				}
+			}
+			for c := 0; c <= cols; c++ {
+				if (c%5 == 0 || c == cols) == bold {
+					fillRect(img, x0+c*size, y0, x0+c*size+1, y0+rows*size+1, gridColor(bold))
+				}
+			}
+		}
+	}
+	return img
+}
+
+func gridColor(bold bool) uint8 {
+	if bold {
+		return pngBlack
+	}
+	return pngLightGray
+}
+
+// WritePNG draws the board with DrawBoard and encodes it as a PNG image.
+func WritePNG(w io.Writer, p Puzzle, board Board, options PNGOptions) error {
+	return png.Encode(w, DrawBoard(p, board, options))
+}
+
+func fillRect(img *image.Paletted, x0 int, y0 int, x1 int, y1 int, c uint8) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			img.SetColorIndex(x, y, c)
+		}
+	}
+}
+
+// drawText draws the text with the bitmap font centered on (cx, cy), each pixel of
+// the font is a square of the given size.
+func drawText(img *image.Paletted, text string, cx int, cy int, pixel int) {
+	width := (4*len(text) - 1) * pixel
+	x, y := cx-width/2, cy-5*pixel/2
+
+	for _, ch := range text {
+		glyph := glyphs[ch]
+		for row, bits := range glyph {
+			for col := 0; col < 3; col++ {
+				if bits&(4>>uint(col)) != 0 {
+					px, py := x+col*pixel, y+row*pixel
+					fillRect(img, px, py, px+pixel, py+pixel, pngBlack)
+				}
+			}
+		}
+		x += 4 * pixel
+	}
+}
@@ -0,0 +1,85 @@
+package solver
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestWritePNG(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	board := NewTreeSolver(puz).Solve()
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, puz, board, PNGOptions{CellSize: 16}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// 3 slots of clues on both sides, the grid and its last line
+	if size := img.Bounds().Size(); size != (image.Point{3*16 + 5*16 + 1, 3*16 + 5*16 + 1}) {
+		t.Errorf("Unexpected size %v", size)
+		t.FailNow()
+	}
+
+	for r := range board {
+		for c := range board[r] {
+			y, x := 3*16+r*16+8, 3*16+c*16+8
+			if black := isBlack(img.At(x, y)); black != (board[r][c] == full) {
+				t.Errorf("Cell (%d, %d) is drawn wrong", r, c)
+				t.FailNow()
+			}
+		}
+	}
+
+	// the 3 of the first row clue is drawn in the last slot to its left
+	if !hasBlack(img, image.Rect(2*16, 3*16, 3*16, 4*16)) || hasBlack(img, image.Rect(0, 3*16, 2*16, 4*16)) {
+		t.Errorf("Expected a single clue to the left of the first row")
+		t.FailNow()
+	}
+}
+
+func TestDrawBoardThumbnail(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("20x20")
+	board := NewTreeSolver(puz).Solve()
+
+	img := DrawBoard(puz, board, PNGOptions{CellSize: 2, NoClues: true})
+
+	if size := img.Bounds().Size(); size != (image.Point{40, 40}) {
+		t.Errorf("Expected a 40x40 thumbnail without grid lines, got %v", size)
+		t.FailNow()
+	}
+	for r := range board {
+		for c := range board[r] {
+			if isBlack(img.At(2*c+1, 2*r+1)) != (board[r][c] == full) {
+				t.Errorf("Cell (%d, %d) is drawn wrong", r, c)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func isBlack(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r == 0 && g == 0 && b == 0
+}
+
+func hasBlack(img image.Image, rect image.Rectangle) bool {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if isBlack(img.At(x, y)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RenderBoard returns a textual representation of the board along with the clues of
+// the puzzle: the row clues right-aligned to the left of each row and the column clues
+// stacked above each column, with the same cells used by Board.String.
+//
+// If grid is true a line is drawn between every 5 rows and every 5 columns.
+// Unknown block lengths and lines without clues are shown as ?.
+func RenderBoard(p Puzzle, board Board, grid bool) string {
+	rowClues := make([]string, len(p.Rows))
+	width := 0
+	for i, constraints := range p.Rows {
+		clues := clueStrings(constraints)
+		rowClues[i] = strings.Join(clues, " ")
+		if len(rowClues[i]) > width {
+			width = len(rowClues[i])
+		}
+	}
+
+	colClues := make([][]string, len(p.Cols))
+	height := 0
+	for i, constraints := range p.Cols {
+		colClues[i] = clueStrings(constraints)
+		if len(colClues[i]) > height {
+			height = len(colClues[i])
+		}
+	}
+
+	separator := func(c int) bool {
+		return grid && c > 0 && c%5 == 0
+	}
+
+	var b strings.Builder
+
+	// column clues, aligned to the bottom
+	for h := 0; h < height; h++ {
+		fmt.Fprintf(&b, "%*s", width+3, "")
+		for c, clues := range colClues {
+			if separator(c) {
+				b.WriteString(" ")
+			}
+			if i := h - (height - len(clues)); i >= 0 {
+				fmt.Fprintf(&b, "%2s ", clues[i])
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	for r, line := range board {
+		if separator(r) {
+			fmt.Fprintf(&b, "%*s", width+1, "")
+			b.WriteString("⎹ ")
+			for c := range line {
+				if separator(c) {
+					b.WriteString("┼")
+				}
+				b.WriteString("───")
+			}
+			b.WriteString(" ⎸\n")
+		}
+
+		fmt.Fprintf(&b, "%*s ", width, rowClues[r])
+		b.WriteString("⎹ ")
+		for c, cell := range line {
+			if separator(c) {
+				b.WriteString("│")
+			}
+			b.WriteString(cellText(cell))
+		}
+		b.WriteString(" ⎸\n")
+	}
+	return b.String()
+}
+
+// cellText returns how a cell is drawn as text: a block if it's full, a cross if it's
+// marked and a blank otherwise, each three characters wide.
+func cellText(cell Cell) string {
+	switch cell {
+	case full:
+		return " ▉ "
+	case marked:
+		return " × "
+	}
+	return "   "
+}
+
+// clueStrings returns the text of each clue of a line.
+func clueStrings(constraints []int) []string {
+	if len(constraints) == 0 {
+		return []string{"?"}
+	}
+
+	clues := make([]string, len(constraints))
+	for i, c := range constraints {
+		if c == Unknown {
+			clues[i] = "?"
+		} else {
+			clues[i] = strconv.Itoa(c)
+		}
+	}
+	return clues
+}
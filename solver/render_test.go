@@ -0,0 +1,55 @@
+package solver
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRenderBoard(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley-hidden")
+	board := NewTreeSolver(puz).Solve()
+
+	expected := "" +
+		"            1     1    \n" +
+		"            ?  3  1    \n" +
+		"         3  1  1  1  3 \n" +
+		"    3 ⎹  ×  ▉  ▉  ▉  ×  ⎸\n" +
+		"1 ? 1 ⎹  ▉  ×  ▉  ×  ▉  ⎸\n" +
+		"    5 ⎹  ▉  ▉  ▉  ▉  ▉  ⎸\n" +
+		"  ? 1 ⎹  ▉  ×  ×  ×  ▉  ⎸\n" +
+		"    ? ⎹  ×  ▉  ▉  ▉  ×  ⎸\n"
+
+	if result := RenderBoard(puz, board, false); result != expected {
+		t.Errorf("Expected\n%s, got\n%s", expected, result)
+		t.FailNow()
+	}
+}
+
+func TestRenderBoardGrid(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("20x20")
+	board := NewTreeSolver(puz).Solve()
+
+	lines := strings.Split(strings.TrimSuffix(RenderBoard(puz, board, true), "\n"), "\n")
+
+	// 4 lines of column clues, 20 rows and 3 grid lines
+	if len(lines) != 4+20+3 {
+		t.Errorf("Expected %d lines, got %d", 4+20+3, len(lines))
+		t.FailNow()
+	}
+	if strings.Count(lines[4+5], "┼") != 3 || strings.Count(lines[4], "│") != 3 {
+		t.Errorf("Expected grid lines every 5 cells, got\n%s\n%s", lines[4], lines[4+5])
+		t.FailNow()
+	}
+
+	// columns of the clues and of the cells are aligned
+	column := func(line string, s string) int {
+		return utf8.RuneCountInString(line[:strings.Index(line, s)])
+	}
+	if column(lines[3], "1") != column(lines[4], "×") {
+		t.Errorf("Misaligned column clues\n%s\n%s", lines[3], lines[4])
+		t.FailNow()
+	}
+}
@@ -0,0 +1,54 @@
+package solver
+
+import (
+	"context"
+	"io"
+)
+
+// A SATSolver solves a nonogram by encoding it as a CNF formula and running the built-in
+// CDCL SAT solver on it.
+//
+// It doesn't rely on the line solver at all, so it can be used to cross-check the results
+// of a TreeSolver and as a fallback for puzzles where its search tree grows too large.
+type SATSolver struct {
+	puzzle Puzzle
+	cnf    *CNF
+
+	// Conflicts and Decisions count the work done by the SAT solver in the last search
+	Conflicts int
+	Decisions int
+}
+
+// NewSATSolver returns a newly created Solver for the given puzzle
+func NewSATSolver(p Puzzle) *SATSolver {
+	return &SATSolver{puzzle: p, cnf: EncodeCNF(p)}
+}
+
+// Solve implements the Solver interface, returning a fully solved Board
+//
+// If the puzzle has no solution the returned Board is empty.
+func (s *SATSolver) Solve() Board {
+	board, _ := s.SolveContext(context.Background())
+	return board
+}
+
+// SolveContext looks for a solution until one is found or ctx is cancelled, ok is false
+// if the puzzle has no solution or the search was stopped, in which case the returned
+// Board is empty. The search was stopped, rather than proving that there is no solution,
+// if ctx.Err() is not nil.
+func (s *SATSolver) SolveContext(ctx context.Context) (board Board, ok bool) {
+	sat := newCDCLSolver(s.cnf)
+	model, ok := sat.solve(ctx)
+	s.Conflicts, s.Decisions = sat.Conflicts, sat.Decisions
+
+	if !ok {
+		return NewBoard(len(s.puzzle.Rows), len(s.puzzle.Cols)), false
+	}
+	return s.cnf.board(model), true
+}
+
+// WriteDIMACS writes the formula encoding the puzzle in the DIMACS CNF format, so that
+// it can be solved by an external SAT solver.
+func (s *SATSolver) WriteDIMACS(w io.Writer) error {
+	return s.cnf.WriteDIMACS(w)
+}
@@ -0,0 +1,123 @@
+package solver
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCDCL(t *testing.T) {
+	// (x1 or x2) and (not x1 or x2) and (not x2 or x3)
+	cnf := &CNF{NumVars: 3, Clauses: [][]int{{1, 2}, {-1, 2}, {-2, 3}}}
+	model, ok := newCDCLSolver(cnf).solve(context.Background())
+
+	if !ok || !model[2] || !model[3] {
+		t.Errorf("Expected a model with x2 and x3, got %v", model)
+		t.FailNow()
+	}
+
+	// four pigeons in three holes, variable 3*p+h+1 puts pigeon p in hole h
+	cnf = &CNF{NumVars: 12}
+	for p := 0; p < 4; p++ {
+		cnf.addClause(3*p+1, 3*p+2, 3*p+3)
+	}
+	for h := 0; h < 3; h++ {
+		for p := 0; p < 4; p++ {
+			for q := p + 1; q < 4; q++ {
+				cnf.addClause(-(3*p + h + 1), -(3*q + h + 1))
+			}
+		}
+	}
+
+	if _, ok = newCDCLSolver(cnf).solve(context.Background()); ok {
+		t.Errorf("Expected the pigeonhole formula to be unsatisfiable")
+		t.FailNow()
+	}
+}
+
+// TestSATSolveAll cross-checks the SAT solver with the TreeSolver on the bundled puzzles.
+func TestSATSolveAll(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		board, ok := NewSATSolver(puz).SolveContext(context.Background())
+
+		if !ok {
+			t.Errorf("Puzzle %s was not solved", puz.Name)
+			t.FailNow()
+		}
+
+		for i, constraints := range puz.Rows {
+			if !hasUnknown(constraints) && !reflect.DeepEqual(lineClues(board[i]), constraints) {
+				t.Errorf("Puzzle %s: row %d doesn't match %v", puz.Name, i, constraints)
+				t.FailNow()
+			}
+		}
+		for i, constraints := range puz.Cols {
+			col := make([]Cell, len(board))
+			for r := range board {
+				col[r] = board[r][i]
+			}
+			if !hasUnknown(constraints) && !reflect.DeepEqual(lineClues(col), constraints) {
+				t.Errorf("Puzzle %s: column %d doesn't match %v", puz.Name, i, constraints)
+				t.FailNow()
+			}
+		}
+
+		// puzzles with unknown clues may have more than one solution
+		if expected := NewTreeSolver(puz).Solve(); !hasUnknownClues(puz) && !reflect.DeepEqual(board, expected) {
+			t.Errorf("Puzzle %s: expected\n%v\ngot\n%v", puz.Name, expected, board)
+			t.FailNow()
+		}
+	}
+}
+
+func hasUnknownClues(p Puzzle) bool {
+	for _, lines := range [][][]int{p.Rows, p.Cols} {
+		for _, constraints := range lines {
+			if len(constraints) == 0 || hasUnknown(constraints) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestSATSolveUnsolvable(t *testing.T) {
+	puz := Puzzle{Name: "broken", Rows: [][]int{{2}, {0}}, Cols: [][]int{{1}, {0}}}
+
+	if _, ok := NewSATSolver(puz).SolveContext(context.Background()); ok {
+		t.Errorf("Expected no solution for %v", puz)
+		t.FailNow()
+	}
+}
+
+func TestSATSolveCancelled(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("20x20")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := NewSATSolver(puz)
+	if _, ok := s.SolveContext(ctx); ok || s.Decisions != 0 {
+		t.Errorf("Expected the search to stop right away, got %d decisions", s.Decisions)
+		t.FailNow()
+	}
+}
+
+func TestWriteDIMACS(t *testing.T) {
+	puz := Puzzle{Name: "single", Rows: [][]int{{1}}, Cols: [][]int{{1}}}
+	var buf bytes.Buffer
+
+	if err := NewSATSolver(puz).WriteDIMACS(&buf); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if !strings.Contains(buf.String(), "p cnf ") {
+		t.Errorf("Missing problem line in\n%s", buf.String())
+		t.FailNow()
+	}
+}
@@ -0,0 +1,178 @@
+package solver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A SaveFile is the progress of a Game, encoded in JSON by Game.Save and read back by
+// ReadSaveFile to resume the game with ResumeGame.
+//
+// The puzzle is identified by PuzzleHash, so that a save still finds its puzzle if the
+// puzzle is renamed, the name is only informative.
+type SaveFile struct {
+	PuzzleHash string `json:"puzzleHash"`
+	PuzzleName string `json:"puzzleName"`
+	// Board has a string for each row, with a # for each full cell, an x for each
+	// marked cell and a . for each empty cell
+	Board []string `json:"board"`
+	// Row and Col are the position of the cursor
+	Row int `json:"row"`
+	Col int `json:"col"`
+	// Elapsed is the time spent playing, encoded as nanoseconds
+	Elapsed time.Duration `json:"elapsedNs"`
+	// Moves are the moves played, oldest first, and Undone the moves undone that can
+	// still be redone, the last one first. Cells are 0 if empty, 1 if full, 2 if marked.
+	Moves  []Move `json:"moves"`
+	Undone []Move `json:"undone"`
+	Hints  int    `json:"hints"`
+}
+
+// PuzzleHash identifies a puzzle by its clues, regardless of its name: it's the hex
+// SHA-256 of the rows and columns encoded in JSON, with fully unknown lines as [].
+func PuzzleHash(p Puzzle) string {
+	clues, _ := json.Marshal([][][]int{hashLines(p.Rows), hashLines(p.Cols)})
+	sum := sha256.Sum256(clues)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashLines returns the lines with the nil ones replaced by empty ones, since both
+// stand for a fully unknown line but they're encoded differently.
+func hashLines(lines [][]int) [][]int {
+	result := make([][]int, len(lines))
+	for i, line := range lines {
+		if line == nil {
+			line = []int{}
+		}
+		result[i] = line
+	}
+	return result
+}
+
+// GetByHash retrieves a puzzle from the json object by its PuzzleHash.
+// it will return an error if no puzzle has the given hash
+func (obj JSONObject) GetByHash(hash string) (p Puzzle, err error) {
+	for _, puzzle := range obj.Puzzles {
+		if PuzzleHash(puzzle) == hash {
+			p = puzzle
+			return
+		}
+	}
+	err = errors.New("No puzzle found with the given hash")
+	return
+}
+
+// Save writes the progress of the game as a SaveFile.
+func (g *Game) Save(w io.Writer) error {
+	save := SaveFile{
+		PuzzleHash: PuzzleHash(g.Puzzle),
+		PuzzleName: g.Puzzle.Name,
+		Board:      boardStrings(g.Board),
+		Row:        g.Row,
+		Col:        g.Col,
+		Elapsed:    g.Elapsed(),
+		Moves:      g.undo,
+		Undone:     g.redo,
+		Hints:      g.Hints,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(save)
+}
+
+// The symbols of the cells in SaveFile.Board, indexed by their value.
+var saveSymbols = [...]byte{empty: '.', full: '#', marked: 'x'}
+
+// boardStrings returns a string for each row of the board, as in SaveFile.Board.
+func boardStrings(board Board) []string {
+	rows := make([]string, len(board))
+	for r, line := range board {
+		text := make([]byte, len(line))
+		for c, cell := range line {
+			text[c] = saveSymbols[cell]
+		}
+		rows[r] = string(text)
+	}
+	return rows
+}
+
+// parseBoard reads a board of the given size written by boardStrings.
+func parseBoard(rows []string, height int, width int) (Board, error) {
+	if len(rows) != height {
+		return nil, fmt.Errorf("Expected %d rows, got %d", height, len(rows))
+	}
+	board := NewBoard(height, width)
+	for r, text := range rows {
+		if len(text) != width {
+			return nil, fmt.Errorf("Expected %d cells in row %d, got %d", width, r+1, len(text))
+		}
+		for c := range board[r] {
+			switch text[c] {
+			case '#':
+				board[r][c] = full
+			case 'x':
+				board[r][c] = marked
+			case '.':
+			default:
+				return nil, fmt.Errorf("Invalid cell %q in row %d", text[c], r+1)
+			}
+		}
+	}
+	return board, nil
+}
+
+// ReadSaveFile reads a SaveFile written by Game.Save.
+func ReadSaveFile(r io.Reader) (save SaveFile, err error) {
+	err = json.NewDecoder(r).Decode(&save)
+	return
+}
+
+// ResumeGame resumes the game saved in the save file, which must be a game of the
+// given puzzle, e.g. found with GetByHash.
+//
+// The board is rebuilt by playing the moves again, so it fails if the board of the
+// save doesn't match them.
+func ResumeGame(ctx context.Context, save SaveFile, p Puzzle) (*Game, error) {
+	if save.PuzzleHash != PuzzleHash(p) {
+		return nil, errors.New("The save file is for a different puzzle")
+	}
+	g, err := NewGame(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := len(p.Rows), len(p.Cols)
+
+	if save.Row < 0 || save.Row >= rows || save.Col < 0 || save.Col >= cols {
+		return nil, fmt.Errorf("The cursor at row %d, column %d is out of the board", save.Row, save.Col)
+	}
+	for _, moves := range [][]Move{save.Moves, save.Undone} {
+		for _, m := range moves {
+			if m.Row < 0 || m.Row >= rows || m.Col < 0 || m.Col >= cols || m.From > marked || m.To > marked {
+				return nil, fmt.Errorf("Invalid move %+v", m)
+			}
+		}
+	}
+	for _, m := range save.Moves {
+		g.play(m)
+	}
+	g.redo = append(g.redo, save.Undone...)
+
+	board, err := parseBoard(save.Board, rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	if board.String() != g.Board.String() {
+		return nil, errors.New("The board of the save file doesn't match the moves")
+	}
+
+	g.Row, g.Col = save.Row, save.Col
+	g.Hints = save.Hints
+	g.elapsed = save.Elapsed
+	return g, nil
+}
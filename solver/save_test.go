@@ -0,0 +1,123 @@
+package solver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveAndResume(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	g, _ := NewGame(context.Background(), puz)
+
+	g.Fill()
+	g.MoveCursor(0, 1)
+	g.Mark()
+	g.Hint()
+	g.Undo()
+	g.MoveCursor(2, 2)
+	g.elapsed = time.Minute
+
+	var buf bytes.Buffer
+	if err := g.Save(&buf); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.Contains(buf.String(), `"#x...",`) {
+		t.Errorf("Unexpected board in\n%s", buf.String())
+		t.FailNow()
+	}
+
+	save, err := ReadSaveFile(&buf)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// the puzzle is found by its clues even when renamed
+	for i := range inputFile.Puzzles {
+		if inputFile.Puzzles[i].Name == "smiley" {
+			inputFile.Puzzles[i].Name = "renamed"
+		}
+	}
+	found, err := inputFile.GetByHash(save.PuzzleHash)
+	if err != nil || found.Name != "renamed" {
+		t.Errorf("Expected to find the renamed puzzle, got %v %v", found.Name, err)
+		t.FailNow()
+	}
+
+	resumed, err := ResumeGame(context.Background(), save, found)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if resumed.Board.String() != g.Board.String() || resumed.Row != g.Row || resumed.Col != g.Col {
+		t.Errorf("Expected the same board and cursor, got\n%v", resumed.Board)
+		t.FailNow()
+	}
+	if resumed.Hints != 1 || resumed.Elapsed() < time.Minute || len(resumed.Moves()) != 2 {
+		t.Errorf("Unexpected %d hints, %d moves after %v", resumed.Hints, len(resumed.Moves()), resumed.Elapsed())
+		t.FailNow()
+	}
+
+	// the hint undone can be redone
+	if !resumed.Redo() || resumed.Board[0][0] != marked {
+		t.Errorf("Expected to redo the hint fixing the first cell")
+		t.FailNow()
+	}
+}
+
+func TestResumeGameErrors(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	other, _ := inputFile.GetByName("mushroom")
+	g, _ := NewGame(context.Background(), puz)
+	g.Fill()
+
+	var buf bytes.Buffer
+	g.Save(&buf)
+	save, _ := ReadSaveFile(&buf)
+
+	if _, err := ResumeGame(context.Background(), save, other); err == nil {
+		t.Errorf("Expected an error for a different puzzle")
+		t.FailNow()
+	}
+
+	save.Board[0] = "....."
+	if _, err := ResumeGame(context.Background(), save, puz); err == nil {
+		t.Errorf("Expected an error for a board not matching the moves")
+		t.FailNow()
+	}
+
+	save.Board[0] = "#...."
+	save.Moves[0].Col = 7
+	if _, err := ResumeGame(context.Background(), save, puz); err == nil {
+		t.Errorf("Expected an error for a move out of the board")
+		t.FailNow()
+	}
+}
+
+func TestPuzzleHash(t *testing.T) {
+	a := Puzzle{Name: "a", Rows: [][]int{{1}, nil}, Cols: [][]int{{Unknown}, {0}}}
+	b := Puzzle{Name: "b", Rows: [][]int{{1}, nil}, Cols: [][]int{{Unknown}, {0}}}
+	c := Puzzle{Name: "a", Rows: [][]int{nil, {1}}, Cols: [][]int{{Unknown}, {0}}}
+
+	if PuzzleHash(a) != PuzzleHash(b) || PuzzleHash(a) == PuzzleHash(c) {
+		t.Errorf("The hash should depend only on the clues")
+		t.FailNow()
+	}
+	// null and [] are both fully unknown lines
+	var d Puzzle
+	if err := json.Unmarshal([]byte(`{"rows": [[1], []], "cols": [[-1], [0]]}`), &d); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if d.Rows[1] == nil || PuzzleHash(a) != PuzzleHash(d) {
+		t.Errorf("Expected the same hash for null and empty lines")
+		t.FailNow()
+	}
+}
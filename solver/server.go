@@ -0,0 +1,361 @@
+package solver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServerOptions configures the limits of a Server, the zero value accepts bodies up to
+// 1 MiB and puzzles up to 40000 cells, and stops solving a puzzle after 10 seconds.
+type ServerOptions struct {
+	// MaxBodySize is the largest request body accepted, in bytes
+	MaxBodySize int64
+	// MaxCells is the largest number of cells of the puzzles accepted, since the memory
+	// used by the solver grows with it
+	MaxCells int
+	// Timeout is the time given to the solver for each request. Hints look for a second
+	// solution until it runs out, since mistakes are only pointed out when the solution
+	// is unique, so they can take the whole Timeout on puzzles with a large search tree
+	Timeout time.Duration
+	// Jobs is the queue solving the puzzles submitted to /jobs, which is only served
+	// if it's not nil
+	Jobs *JobQueue
+}
+
+func (o ServerOptions) withDefaults() ServerOptions {
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = 1 << 20
+	}
+	if o.MaxCells <= 0 {
+		o.MaxCells = 40000
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// A Server exposes the solver as a JSON API over HTTP:
+//
+//	GET  /puzzles          lists the puzzles of the loaded file
+//	GET  /puzzles/{name}   returns the puzzle with the given name
+//	POST /solve            solves the puzzle in the body
+//	POST /verify           tells if the puzzle in the body has a unique solution
+//	POST /rate             rates how hard the puzzle in the body is
+//	POST /hint             gives a hint for the puzzle in the body, from its "board"
+//	POST /solve/events     solves the puzzle in the body, streaming the progress as
+//	                       Server-Sent Events
+//	POST /jobs             submits the puzzle in the body, or its "puzzles", to the job queue
+//	GET  /jobs/{id}        returns the status of a job, with its result once finished
+//	DELETE /jobs/{id}      cancels a job
+//
+// Boards are encoded as in SaveFile.Board. Errors are returned as an object with the
+// HTTP status, a short code and a message, e.g.
+//
+//	{"error": {"status": 404, "code": "not_found", "message": "No puzzle found with the given name"}}
+type Server struct {
+	puzzles JSONObject
+	options ServerOptions
+	mux     *http.ServeMux
+}
+
+// NewServer creates a Server for the puzzles loaded from a file.
+func NewServer(puzzles JSONObject, options ServerOptions) *Server {
+	s := &Server{puzzles: puzzles, options: options.withDefaults(), mux: http.NewServeMux()}
+	s.handle("GET", "/puzzles", s.listPuzzles)
+	s.handle("GET", "/puzzles/", s.getPuzzle)
+	s.handle("POST", "/solve", s.solve)
+	s.handle("POST", "/verify", s.verify)
+	s.handle("POST", "/rate", s.rate)
+	s.handle("POST", "/hint", s.hint)
+	s.route("POST", "/solve/events", s.solveEvents)
+	if s.options.Jobs != nil {
+		s.handle("POST", "/jobs", s.submitJobs)
+		s.handle("", "/jobs/", s.job)
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errNotFound("Unknown endpoint "+r.URL.Path))
+	})
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// An apiError is an error returned to the client with the given status and code.
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func errBadRequest(message string) *apiError {
+	return &apiError{http.StatusBadRequest, "bad_request", message}
+}
+
+func errNotFound(message string) *apiError {
+	return &apiError{http.StatusNotFound, "not_found", message}
+}
+
+func errUnsolvable() *apiError {
+	return &apiError{http.StatusUnprocessableEntity, "unsolvable", "The puzzle has no solution"}
+}
+
+func errTimeout() *apiError {
+	return &apiError{http.StatusServiceUnavailable, "timeout", "The solver ran out of time"}
+}
+
+// handle registers a handler for the given method and path, or for any method if empty,
+// giving it a context with the request timeout and writing the value it returns as JSON,
+// or the error it returns.
+func (s *Server) handle(method string, path string, handler func(ctx context.Context, r *http.Request) (interface{}, error)) {
+	s.route(method, path, func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		result, err := handler(ctx, r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+// route registers a handler writing its own response for the given method and path,
+// limiting the size of the body and giving the handler a context with the request timeout.
+func (s *Server) route(method string, path string, handler func(ctx context.Context, w http.ResponseWriter, r *http.Request)) {
+	s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if method != "" && r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, &apiError{http.StatusMethodNotAllowed, "method_not_allowed", "Use " + method + " for " + path})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.options.MaxBodySize)
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.options.Timeout)
+		defer cancel()
+		handler(ctx, w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes the error as an apiError, errors of other types are internal errors.
+func writeError(w http.ResponseWriter, err error) {
+	var e *apiError
+	if !errors.As(err, &e) {
+		e = &apiError{http.StatusInternalServerError, "internal", err.Error()}
+	}
+	writeJSON(w, e.Status, map[string]*apiError{"error": e})
+}
+
+// puzzleJSON is a puzzle as returned by the server, in the format of the puzzle files.
+type puzzleJSON struct {
+	Name string  `json:"name"`
+	Rows [][]int `json:"rows"`
+	Cols [][]int `json:"cols"`
+	Hash string  `json:"hash"`
+}
+
+func newPuzzleJSON(p Puzzle) puzzleJSON {
+	return puzzleJSON{Name: p.Name, Rows: p.Rows, Cols: p.Cols, Hash: PuzzleHash(p)}
+}
+
+func (s *Server) listPuzzles(ctx context.Context, r *http.Request) (interface{}, error) {
+	type summary struct {
+		Name string `json:"name"`
+		Rows int    `json:"rows"`
+		Cols int    `json:"cols"`
+		Hash string `json:"hash"`
+	}
+	puzzles := make([]summary, len(s.puzzles.Puzzles))
+	for i, p := range s.puzzles.Puzzles {
+		puzzles[i] = summary{p.Name, len(p.Rows), len(p.Cols), PuzzleHash(p)}
+	}
+	return map[string]interface{}{"puzzles": puzzles}, nil
+}
+
+func (s *Server) getPuzzle(ctx context.Context, r *http.Request) (interface{}, error) {
+	p, err := s.puzzles.GetByName(strings.TrimPrefix(r.URL.Path, "/puzzles/"))
+	if err != nil {
+		return nil, errNotFound(err.Error())
+	}
+	return newPuzzleJSON(p), nil
+}
+
+// readBody reads the body of the request, failing if it's over the size limit.
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		err = &apiError{http.StatusRequestEntityTooLarge, "too_large",
+			fmt.Sprintf("The body is larger than %d bytes", tooLarge.Limit)}
+	}
+	return body, err
+}
+
+// readPuzzle decodes the puzzle in the body of the request, along with the other fields
+// of the body into extra if it's not nil.
+func (s *Server) readPuzzle(r *http.Request, extra interface{}) (p Puzzle, err error) {
+	body, err := readBody(r)
+	if err != nil {
+		return
+	}
+	return s.decodePuzzle(body, extra)
+}
+
+// decodePuzzle decodes and checks a puzzle, along with the other fields of the JSON
+// object into extra if it's not nil.
+func (s *Server) decodePuzzle(data []byte, extra interface{}) (p Puzzle, err error) {
+	if err = json.Unmarshal(data, &p); err == nil && extra != nil {
+		err = json.Unmarshal(data, extra)
+	}
+	if err != nil {
+		return p, errBadRequest("Invalid puzzle: " + err.Error())
+	}
+	return p, s.checkPuzzle(p)
+}
+
+// checkPuzzle fails if the puzzle has no rows or columns, or if it's too large.
+func (s *Server) checkPuzzle(p Puzzle) error {
+	if len(p.Rows) == 0 || len(p.Cols) == 0 {
+		return errBadRequest("The puzzle needs rows and columns")
+	}
+	if len(p.Rows)*len(p.Cols) > s.options.MaxCells {
+		return &apiError{http.StatusRequestEntityTooLarge, "too_large",
+			fmt.Sprintf("The puzzle has more than %d cells", s.options.MaxCells)}
+	}
+	return nil
+}
+
+// solvePuzzle finds the first solution of the puzzle within the timeout of ctx.
+func solvePuzzle(ctx context.Context, p Puzzle) (Board, Stats, error) {
+	t := NewTreeSolver(p)
+	board, ok := t.SolveContext(ctx)
+
+	switch {
+	case ctx.Err() != nil:
+		return nil, t.Stats(), errTimeout()
+	case !ok:
+		return nil, t.Stats(), errUnsolvable()
+	}
+	return board, t.Stats(), nil
+}
+
+func (s *Server) solve(ctx context.Context, r *http.Request) (interface{}, error) {
+	p, err := s.readPuzzle(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	board, stats, err := solvePuzzle(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"board": boardStrings(board), "stats": stats}, nil
+}
+
+func (s *Server) verify(ctx context.Context, r *http.Request) (interface{}, error) {
+	p, err := s.readPuzzle(r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// two solutions are enough to tell that it's not unique
+	solutions := make([][]string, 0, 2)
+	searchCtx, cancel := context.WithCancel(ctx)
+	for board := range NewTreeSolver(p).Solutions(searchCtx) {
+		// the search may still send a solution while stopping
+		if len(solutions) < 2 {
+			solutions = append(solutions, boardStrings(board))
+		}
+		if len(solutions) == 2 {
+			cancel()
+		}
+	}
+	cancel()
+	// the answer is known once two solutions are found, even if time ran out meanwhile
+	if len(solutions) < 2 && ctx.Err() != nil {
+		return nil, errTimeout()
+	}
+	return map[string]interface{}{
+		"solvable":  len(solutions) > 0,
+		"unique":    len(solutions) == 1,
+		"solutions": solutions,
+	}, nil
+}
+
+// Difficulty rates a puzzle by the search needed to solve it with a TreeSolver: easy
+// puzzles are solved by the line solver alone, medium ones need guessing a cell at a time
+// and hard ones need guesses on top of other guesses.
+func Difficulty(stats Stats) string {
+	switch {
+	case stats.Guesses == 0:
+		return "easy"
+	case stats.MaxDepth <= 1:
+		return "medium"
+	}
+	return "hard"
+}
+
+func (s *Server) rate(ctx context.Context, r *http.Request) (interface{}, error) {
+	p, err := s.readPuzzle(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	_, stats, err := solvePuzzle(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"difficulty": Difficulty(stats), "stats": stats}, nil
+}
+
+func (s *Server) hint(ctx context.Context, r *http.Request) (interface{}, error) {
+	var extra struct {
+		Board []string `json:"board"`
+	}
+	p, err := s.readPuzzle(r, &extra)
+	if err != nil {
+		return nil, err
+	}
+	solution, unique, ok := gameSolution(ctx, p)
+	switch {
+	case !ok && ctx.Err() != nil:
+		return nil, errTimeout()
+	case !ok:
+		return nil, errUnsolvable()
+	}
+
+	g := newGame(p, solution, unique)
+	if extra.Board != nil {
+		if g.Board, err = parseBoard(extra.Board, len(p.Rows), len(p.Cols)); err != nil {
+			return nil, errBadRequest("Invalid board: " + err.Error())
+		}
+	}
+	if g.Solved() {
+		return map[string]interface{}{"solved": true, "board": boardStrings(g.Board)}, nil
+	}
+
+	message := g.Hint()
+	return map[string]interface{}{
+		"solved":  false,
+		"message": message,
+		"row":     g.Row,
+		"col":     g.Col,
+		"cell":    g.Board[g.Row][g.Col].String(),
+		"board":   boardStrings(g.Board),
+	}, nil
+}
@@ -0,0 +1,170 @@
+package solver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// request sends a request to the server, decoding the JSON response in result and
+// failing if the status is not the expected one.
+func request(t *testing.T, s *Server, method string, path string, body string, status int, result interface{}) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != status {
+		t.Errorf("%s %s: expected status %d, got %d\n%s", method, path, status, rec.Code, rec.Body)
+		t.FailNow()
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s %s: unexpected content type %s", method, path, ct)
+		t.FailNow()
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), result); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+}
+
+const smileyJSON = `{"name": "smiley", "rows": [[3],[1,1,1],[5],[1,1],[3]], "cols": [[3],[1,1,1],[3,1],[1,1,1],[3]]}`
+
+func TestServerPuzzles(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	s := NewServer(inputFile, ServerOptions{})
+
+	var list struct {
+		Puzzles []struct {
+			Name string
+			Rows int
+		}
+	}
+	request(t, s, "GET", "/puzzles", "", http.StatusOK, &list)
+	if len(list.Puzzles) != len(inputFile.Puzzles) || list.Puzzles[0].Name != inputFile.Puzzles[0].Name {
+		t.Errorf("Unexpected list %+v", list)
+		t.FailNow()
+	}
+
+	var puz Puzzle
+	request(t, s, "GET", "/puzzles/smiley", "", http.StatusOK, &puz)
+	if puz.Name != "smiley" || len(puz.Rows) != 5 || puz.Cols[2][1] != 1 {
+		t.Errorf("Unexpected puzzle %+v", puz)
+		t.FailNow()
+	}
+
+	var e struct{ Error apiError }
+	request(t, s, "GET", "/puzzles/missing", "", http.StatusNotFound, &e)
+	if e.Error.Code != "not_found" || e.Error.Status != http.StatusNotFound {
+		t.Errorf("Unexpected error %+v", e)
+		t.FailNow()
+	}
+	request(t, s, "POST", "/puzzles", "", http.StatusMethodNotAllowed, &e)
+	request(t, s, "GET", "/nothing", "", http.StatusNotFound, &e)
+}
+
+func TestServerSolve(t *testing.T) {
+	s := NewServer(JSONObject{}, ServerOptions{})
+
+	var solved struct {
+		Board []string
+		Stats Stats
+	}
+	request(t, s, "POST", "/solve", smileyJSON, http.StatusOK, &solved)
+	if strings.Join(solved.Board, "/") != "x###x/#x#x#/#####/#xxx#/x###x" || solved.Stats.LineSolves == 0 {
+		t.Errorf("Unexpected solution %+v", solved)
+		t.FailNow()
+	}
+
+	var e struct{ Error apiError }
+	request(t, s, "POST", "/solve", `{"rows": [[1]], "cols": [[0]]}`, http.StatusUnprocessableEntity, &e)
+	request(t, s, "POST", "/solve", `{"rows": [[1]]`, http.StatusBadRequest, &e)
+	request(t, s, "POST", "/solve", `{"rows": [], "cols": [[1]]}`, http.StatusBadRequest, &e)
+}
+
+func TestServerVerifyAndRate(t *testing.T) {
+	s := NewServer(JSONObject{}, ServerOptions{})
+
+	var verified struct {
+		Solvable  bool
+		Unique    bool
+		Solutions [][]string
+	}
+	request(t, s, "POST", "/verify", smileyJSON, http.StatusOK, &verified)
+	if !verified.Solvable || !verified.Unique {
+		t.Errorf("Expected a unique solution, got %+v", verified)
+		t.FailNow()
+	}
+	request(t, s, "POST", "/verify", `{"rows": [[1],[1]], "cols": [[1],[1]]}`, http.StatusOK, &verified)
+	if !verified.Solvable || verified.Unique || len(verified.Solutions) != 2 {
+		t.Errorf("Expected two solutions, got %+v", verified)
+		t.FailNow()
+	}
+
+	var rated struct{ Difficulty string }
+	request(t, s, "POST", "/rate", smileyJSON, http.StatusOK, &rated)
+	if rated.Difficulty != "easy" {
+		t.Errorf("Expected an easy puzzle, got %s", rated.Difficulty)
+		t.FailNow()
+	}
+	request(t, s, "POST", "/rate", `{"rows": [[1],[1]], "cols": [[1],[1]]}`, http.StatusOK, &rated)
+	if rated.Difficulty != "medium" {
+		t.Errorf("Expected a medium puzzle, got %s", rated.Difficulty)
+		t.FailNow()
+	}
+}
+
+func TestServerHint(t *testing.T) {
+	s := NewServer(JSONObject{}, ServerOptions{})
+	body := strings.TrimSuffix(smileyJSON, "}") + `, "board": ["#....", ".....", ".....", ".....", "....."]}`
+
+	var hint struct {
+		Solved  bool
+		Message string
+		Row     int
+		Col     int
+		Cell    string
+	}
+	request(t, s, "POST", "/hint", body, http.StatusOK, &hint)
+	if hint.Solved || hint.Row != 0 || hint.Col != 0 || hint.Cell != "marked" || !strings.Contains(hint.Message, "wrong") {
+		t.Errorf("Expected the mistake to be fixed, got %+v", hint)
+		t.FailNow()
+	}
+
+	var e struct{ Error apiError }
+	body = strings.TrimSuffix(smileyJSON, "}") + `, "board": ["#...."]}`
+	request(t, s, "POST", "/hint", body, http.StatusBadRequest, &e)
+}
+
+func TestServerLimits(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	forever, _ := inputFile.GetByName("forever")
+	data, _ := json.Marshal(forever)
+
+	var e struct{ Error apiError }
+	s := NewServer(JSONObject{}, ServerOptions{MaxBodySize: 64})
+	request(t, s, "POST", "/solve", string(data), http.StatusRequestEntityTooLarge, &e)
+	if e.Error.Code != "too_large" {
+		t.Errorf("Unexpected error %+v", e)
+		t.FailNow()
+	}
+
+	// the size of the puzzle is limited too, not only the one of the body
+	s = NewServer(JSONObject{}, ServerOptions{MaxCells: 25})
+	request(t, s, "POST", "/solve", smileyJSON, http.StatusOK, &struct{}{})
+	request(t, s, "POST", "/solve", `{"rows": [[1],[1],[1],[1],[1]], "cols": [[1],[1],[1],[1],[1],[0]]}`, http.StatusRequestEntityTooLarge, &e)
+	if e.Error.Code != "too_large" {
+		t.Errorf("Unexpected error %+v", e)
+		t.FailNow()
+	}
+
+	s = NewServer(JSONObject{}, ServerOptions{Timeout: time.Millisecond})
+	start := time.Now()
+	request(t, s, "POST", "/verify", string(data), http.StatusServiceUnavailable, &e)
+	if e.Error.Code != "timeout" || time.Since(start) > time.Second {
+		t.Errorf("Expected a timeout, got %+v after %v", e, time.Since(start))
+		t.FailNow()
+	}
+}
@@ -19,28 +19,29 @@ func (board Board) String() string {
 		buffer.WriteString("\u23b9 ")
 
 		for _, cell := range line {
-			switch cell {
-			case full:
-				// unicode - \u2589
-				buffer.WriteString(" ▉ ")
-			case marked:
-				// unicode - \u00d7
-				buffer.WriteString(" × ")
-			case empty:
-				buffer.WriteString("   ")
-			}
+			buffer.WriteString(cellText(cell))
 		}
 		buffer.WriteString(" \u23b8\n")
 	}
 	return buffer.String()
 }
 
+func (board Board) clone() Board {
+	b := make([][]Cell, len(board))
+
+	for i := range board {
+		b[i] = make([]Cell, len(board[i]))
+		copy(b[i], board[i])
+	}
+	return b
+}
+
 // Cell is an enum used for values of single cells in the Board
 // A cell can be in three states:
 // 	- empty, when the solver has not yet made any assumption on it
 //  - marked, when the solver is certain the cell is NOT part of the picture
 //  - full, when the cell is part of the picture in the nonogram
-type Cell int
+type Cell uint8
 
 const (
 	empty Cell = iota
@@ -48,6 +49,17 @@ const (
 	marked
 )
 
+// String returns the name of the value of the cell: empty, full or marked.
+func (c Cell) String() string {
+	switch c {
+	case full:
+		return "full"
+	case marked:
+		return "marked"
+	}
+	return "empty"
+}
+
 // NewBoard creates a new empty board with the specified columns and rows
 func NewBoard(rows int, columns int) (b Board) {
 	b = make([][]Cell, rows)
@@ -0,0 +1,97 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Stats counts the work done by a TreeSolver, it's returned by TreeSolver.Stats.
+//
+// Times are encoded in JSON as nanoseconds.
+type Stats struct {
+	// LineSolves is the number of times the line solver ran on a line
+	LineSolves int `json:"lineSolves"`
+	// LineChanges is the number of line solver runs that changed their line, filling
+	// CellChanges cells overall
+	LineChanges int `json:"lineChanges"`
+	CellChanges int `json:"cellChanges"`
+	// Contradictions is the number of branches of the search tree that turned out
+	// to have no solution
+	Contradictions int `json:"contradictions"`
+	// Guesses is the number of cells guessed, Backtracks the number of times a guess
+	// was undone to try the other value and MaxDepth the largest number of guesses
+	// made on top of each other
+	Guesses    int `json:"guesses"`
+	Backtracks int `json:"backtracks"`
+	MaxDepth   int `json:"maxDepth"`
+	// CacheHits and CacheMisses count the lookups in the line cache, if any
+	CacheHits   int `json:"cacheHits"`
+	CacheMisses int `json:"cacheMisses"`
+	// LogicTime is the time spent running the line solver to a fixpoint, SearchTime
+	// the rest of the time spent searching for solutions
+	LogicTime  time.Duration `json:"logicTimeNs"`
+	SearchTime time.Duration `json:"searchTimeNs"`
+	TotalTime  time.Duration `json:"totalTimeNs"`
+}
+
+// add adds the counters of o to s, as when merging the work of several solvers.
+func (s *Stats) add(o Stats) {
+	s.LineSolves += o.LineSolves
+	s.LineChanges += o.LineChanges
+	s.CellChanges += o.CellChanges
+	s.Contradictions += o.Contradictions
+	s.Guesses += o.Guesses
+	s.Backtracks += o.Backtracks
+	if o.MaxDepth > s.MaxDepth {
+		s.MaxDepth = o.MaxDepth
+	}
+	s.CacheHits += o.CacheHits
+	s.CacheMisses += o.CacheMisses
+	s.LogicTime += o.LogicTime
+}
+
+func (s Stats) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Line solves:    %d\n", s.LineSolves)
+	fmt.Fprintf(&b, "Line changes:   %d (%d cells)\n", s.LineChanges, s.CellChanges)
+	fmt.Fprintf(&b, "Contradictions: %d\n", s.Contradictions)
+	fmt.Fprintf(&b, "Guesses:        %d\n", s.Guesses)
+	fmt.Fprintf(&b, "Backtracks:     %d\n", s.Backtracks)
+	fmt.Fprintf(&b, "Max depth:      %d\n", s.MaxDepth)
+	fmt.Fprintf(&b, "Cache:          %d hits, %d misses\n", s.CacheHits, s.CacheMisses)
+	fmt.Fprintf(&b, "Logic time:     %v\n", s.LogicTime)
+	fmt.Fprintf(&b, "Search time:    %v\n", s.SearchTime)
+	fmt.Fprintf(&b, "Total time:     %v", s.TotalTime)
+	return b.String()
+}
+
+// Stats returns the work done by the solver so far, over all the calls to Solve,
+// Solutions and ParallelSearch.
+func (t *TreeSolver) Stats() Stats {
+	stats := t.stats
+	stats.Guesses = t.GuessCount
+	stats.SearchTime = stats.TotalTime - stats.LogicTime
+	if stats.SearchTime < 0 {
+		// the logic time of a parallel search is summed over all the goroutines
+		stats.SearchTime = 0
+	}
+	return stats
+}
+
+// lineSolved records a run of the line solver that changed the given cells.
+func (s *Stats) lineSolved(changed bitset, hit bool, cached bool) {
+	s.LineSolves++
+	if changed != nil {
+		s.LineChanges++
+		s.CellChanges += changed.count()
+	}
+	if cached {
+		if hit {
+			s.CacheHits++
+		} else {
+			s.CacheMisses++
+		}
+	}
+}
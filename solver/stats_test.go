@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	// solved by the line solver alone, every cell changes exactly once
+	puz, _ := inputFile.GetByName("mushroom")
+	s := NewTreeSolver(puz)
+	s.Solve()
+	stats := s.Stats()
+
+	if stats.CellChanges != 100 || stats.LineChanges == 0 || stats.LineSolves < stats.LineChanges {
+		t.Errorf("Unexpected line counts %+v", stats)
+		t.FailNow()
+	}
+	if stats.Guesses != 0 || stats.Backtracks != 0 || stats.MaxDepth != 0 || stats.Contradictions != 0 {
+		t.Errorf("Unexpected search counts %+v", stats)
+		t.FailNow()
+	}
+	if stats.TotalTime <= 0 || stats.LogicTime > stats.TotalTime {
+		t.Errorf("Unexpected times %+v", stats)
+		t.FailNow()
+	}
+
+	// needs guessing
+	puz, _ = inputFile.GetByName("edge")
+	s = NewTreeSolver(puz, WithLineCache(NewLineCache(1000)))
+	s.Solve()
+	stats = s.Stats()
+
+	if stats.Guesses != s.GuessCount || stats.Guesses == 0 || stats.MaxDepth == 0 || stats.Contradictions == 0 {
+		t.Errorf("Unexpected search counts %+v", stats)
+		t.FailNow()
+	}
+	if stats.CacheHits+stats.CacheMisses != stats.LineSolves || stats.CacheHits == 0 {
+		t.Errorf("Unexpected cache counts %+v", stats)
+		t.FailNow()
+	}
+
+	// the work of all the goroutines of a parallel search is merged
+	s = NewTreeSolver(puz)
+	s.ParallelSearch(context.Background(), 2, 0)
+	stats = s.Stats()
+
+	if stats.Guesses == 0 || stats.Backtracks == 0 || stats.MaxDepth == 0 || stats.Contradictions == 0 || stats.LineSolves == 0 {
+		t.Errorf("Unexpected parallel search counts %+v", stats)
+		t.FailNow()
+	}
+}
+
+func TestStatsBacktracks(t *testing.T) {
+	// two solutions, found by guessing the first cell full and then marked
+	puz := Puzzle{Rows: [][]int{{1}, {1}}, Cols: [][]int{{1}, {1}}}
+
+	s := NewTreeSolver(puz)
+	for range s.Solutions(context.Background()) {
+	}
+	if stats := s.Stats(); stats.Guesses != 2 || stats.Backtracks != 1 {
+		t.Errorf("Expected a single backtrack, got %+v", stats)
+		t.FailNow()
+	}
+
+	s = NewTreeSolver(puz)
+	s.ParallelSearch(context.Background(), 2, 0)
+	if stats := s.Stats(); stats.Backtracks != 1 {
+		t.Errorf("Expected a single backtrack in the parallel search, got %+v", stats)
+		t.FailNow()
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	data, err := json.Marshal(Stats{LineSolves: 3, MaxDepth: 2})
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, key := range []string{`"lineSolves":3`, `"maxDepth":2`, `"totalTimeNs":0`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("Missing %s in %s", key, data)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package solver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// progress are the totals sent with every event by the events endpoint of a Server.
+type progress struct {
+	LinesSolved int     `json:"linesSolved"`
+	Guesses     int     `json:"guesses"`
+	Percent     float64 `json:"percent"`
+}
+
+// lineEvent is sent when a line deduces some cells.
+type lineEvent struct {
+	Line  string `json:"line"`
+	Index int    `json:"index"`
+	Cells int    `json:"cells"`
+	progress
+}
+
+// cellEvent is sent when a cell is guessed, or when the guess is undone.
+type cellEvent struct {
+	Row   int    `json:"row"`
+	Col   int    `json:"col"`
+	Value string `json:"value,omitempty"`
+	Depth int    `json:"depth"`
+	progress
+}
+
+// A streamObserver is an Observer sending the progress of a solver as events on a
+// channel. It never blocks the solver: events are dropped while the channel is full,
+// which loses nothing but the details of a step since every event has the totals.
+type streamObserver struct {
+	BaseObserver
+	events      chan sseEvent
+	linesSolved int
+	guesses     int
+}
+
+// An sseEvent is a Server-Sent Event, its data is encoded in JSON.
+type sseEvent struct {
+	name string
+	data interface{}
+}
+
+// send sends the event unless the channel is full, filling in its progress. The solver
+// is the only sender, so the progress is computed only when the event can be sent.
+func (o *streamObserver) send(name string, e interface{}, p *progress, board Board) {
+	if len(o.events) == cap(o.events) {
+		return
+	}
+	*p = progress{LinesSolved: o.linesSolved, Guesses: o.guesses, Percent: determined(board)}
+	select {
+	case o.events <- sseEvent{name, e}:
+	default:
+	}
+}
+
+// CellsChanged implements Observer, only the lines that deduced some cells are sent.
+func (o *streamObserver) CellsChanged(lt LineType, index int, cells []int, board Board) {
+	e := &lineEvent{Line: lt.String(), Index: index, Cells: len(cells)}
+	o.send("line", e, &e.progress, board)
+}
+
+// LineSolved implements Observer
+func (o *streamObserver) LineSolved(lt LineType, index int, board Board) {
+	o.linesSolved++
+}
+
+// Guess implements Observer
+func (o *streamObserver) Guess(r int, c int, value Cell, depth int, board Board) {
+	o.guesses++
+	e := &cellEvent{Row: r, Col: c, Value: value.String(), Depth: depth}
+	o.send("guess", e, &e.progress, board)
+}
+
+// Backtrack implements Observer
+func (o *streamObserver) Backtrack(r int, c int, depth int, board Board) {
+	e := &cellEvent{Row: r, Col: c, Depth: depth}
+	o.send("backtrack", e, &e.progress, board)
+}
+
+// determined returns the percentage of the cells of the board that are not empty.
+func determined(board Board) float64 {
+	known, total := 0, 0
+	for _, line := range board {
+		for _, cell := range line {
+			if cell != empty {
+				known++
+			}
+		}
+		total += len(line)
+	}
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(known) / float64(total)
+}
+
+// solveEvents solves the puzzle in the body streaming line, guess and backtrack events,
+// each one with the lines solved, the guesses and the percentage of cells determined so
+// far. The stream ends with a solved event with the board and the stats of the solver,
+// or an error event with the same object as the other errors of the server.
+func (s *Server) solveEvents(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	p, err := s.readPuzzle(r, nil)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, fmt.Errorf("Streaming is not supported"))
+		return
+	}
+
+	observer := &streamObserver{events: make(chan sseEvent, 64)}
+	t := NewTreeSolver(p, WithObserver(observer))
+	done := make(chan sseEvent, 1)
+
+	go func() {
+		defer close(observer.events)
+		board, ok := t.SolveContext(ctx)
+		switch {
+		case ctx.Err() != nil:
+			done <- sseEvent{"error", errTimeout()}
+		case !ok:
+			done <- sseEvent{"error", errUnsolvable()}
+		default:
+			done <- sseEvent{"solved", map[string]interface{}{"board": boardStrings(board), "stats": t.Stats()}}
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for e := range observer.events {
+		writeEvent(w, e)
+		flusher.Flush()
+	}
+	writeEvent(w, <-done)
+	flusher.Flush()
+}
+
+func writeEvent(w http.ResponseWriter, e sseEvent) {
+	data, _ := json.Marshal(e.data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, data)
+}
@@ -0,0 +1,109 @@
+package solver
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+// streamEvents posts the puzzle to the events endpoint, returning the events received.
+func streamEvents(t *testing.T, s *Server, body string) []testEvent {
+	req := httptest.NewRequest("POST", "/solve/events", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Unexpected response %d\n%s", rec.Code, rec.Body)
+		t.FailNow()
+	}
+
+	var events []testEvent
+	scanner := bufio.NewScanner(rec.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			events = append(events, testEvent{name: strings.TrimPrefix(line, "event: ")})
+		case strings.HasPrefix(line, "data: "):
+			e := &events[len(events)-1]
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.data); err != nil {
+				t.Error(err)
+				t.FailNow()
+			}
+		}
+	}
+	return events
+}
+
+func TestSolveEvents(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("20x20")
+	data, _ := json.Marshal(puz)
+	s := NewServer(JSONObject{}, ServerOptions{})
+
+	events := streamEvents(t, s, string(data))
+	last := events[len(events)-1]
+	if last.name != "solved" || last.data["board"] == nil {
+		t.Errorf("Expected to end with the solution, got %v", last)
+		t.FailNow()
+	}
+
+	lines, guesses := 0, 0
+	percent := 0.0
+	for _, e := range events[:len(events)-1] {
+		switch e.name {
+		case "line":
+			lines++
+			if p := e.data["percent"].(float64); p < percent && guesses == 0 {
+				t.Errorf("The percentage went down from %v to %v without guesses", percent, p)
+				t.FailNow()
+			} else {
+				percent = p
+			}
+		case "guess":
+			guesses++
+		}
+	}
+	if lines == 0 || percent == 0 {
+		t.Errorf("Expected line events, got %d", lines)
+		t.FailNow()
+	}
+}
+
+func TestSolveEventsErrors(t *testing.T) {
+	s := NewServer(JSONObject{}, ServerOptions{})
+	events := streamEvents(t, s, `{"rows": [[1]], "cols": [[0]]}`)
+	if last := events[len(events)-1]; last.name != "error" || last.data["code"] != "unsolvable" {
+		t.Errorf("Expected to end with an error, got %v", last)
+		t.FailNow()
+	}
+
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	forever, _ := inputFile.GetByName("forever")
+	data, _ := json.Marshal(forever)
+	s = NewServer(JSONObject{}, ServerOptions{Timeout: 50 * time.Millisecond})
+
+	events = streamEvents(t, s, string(data))
+	if last := events[len(events)-1]; last.name != "error" || last.data["code"] != "timeout" {
+		t.Errorf("Expected to end with a timeout, got %v", last)
+		t.FailNow()
+	}
+	if events[0].name != "line" {
+		t.Errorf("Expected some progress before the timeout, got %v", events[0])
+		t.FailNow()
+	}
+
+	// errors found before streaming are plain JSON
+	var e struct{ Error apiError }
+	request(t, s, "POST", "/solve/events", "{", http.StatusBadRequest, &e)
+}
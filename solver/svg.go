@@ -0,0 +1,136 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// SVGOptions configures how puzzles and boards are drawn by WriteSVG, the zero value
+// draws 20 pixel cells with clues in a sans-serif font and no marks.
+type SVGOptions struct {
+	// CellSize is the side of a cell in pixels
+	CellSize int
+	// FontFamily and FontSize are used for the clues, the font size defaults to
+	// 60% of the cell size
+	FontFamily string
+	FontSize   int
+	// Marks draws a × in the marked cells
+	Marks bool
+	// NoClues leaves out the clues, drawing only the grid
+	NoClues bool
+}
+
+func (o SVGOptions) withDefaults() SVGOptions {
+	if o.CellSize <= 0 {
+		o.CellSize = 20
+	}
+	if o.FontFamily == "" {
+		o.FontFamily = "sans-serif"
+	}
+	if o.FontSize <= 0 {
+		o.FontSize = o.CellSize * 3 / 5
+	}
+	return o
+}
+
+// WriteSVG draws the puzzle as an SVG image: the row clues to the left of the grid and
+// the column clues above it, with bold lines every 5 cells. The full cells of the board
+// are filled in, a nil board draws the blank puzzle.
+func WriteSVG(w io.Writer, p Puzzle, board Board, options SVGOptions) error {
+	o := options.withDefaults()
+	size := o.CellSize
+	rows, cols := len(p.Rows), len(p.Cols)
+
+	// room for the clues, one cell for each of them
+	left, top := 0, 0
+	if !o.NoClues {
+		for _, constraints := range p.Rows {
+			if n := len(clueStrings(constraints)); n > left {
+				left = n
+			}
+		}
+		for _, constraints := range p.Cols {
+			if n := len(clueStrings(constraints)); n > top {
+				top = n
+			}
+		}
+	}
+	// half a cell of margin around the image
+	margin := size / 2
+	x0, y0 := margin+left*size, margin+top*size
+	width, height := x0+cols*size+margin, y0+rows*size+margin
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height)
+	fmt.Fprintf(bw, "<title>%s</title>\n", html.EscapeString(p.Name))
+	fmt.Fprintf(bw, "<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", width, height)
+
+	if !o.NoClues {
+		fmt.Fprintf(bw, "<g font-family=\"%s\" font-size=\"%d\" text-anchor=\"middle\" dominant-baseline=\"central\">\n",
+			html.EscapeString(o.FontFamily), o.FontSize)
+		for r, constraints := range p.Rows {
+			clues := clueStrings(constraints)
+			for i, clue := range clues {
+				x := x0 - (len(clues)-i)*size + size/2
+				fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\">%s</text>\n", x, y0+r*size+size/2, clue)
+			}
+		}
+		for c, constraints := range p.Cols {
+			clues := clueStrings(constraints)
+			for i, clue := range clues {
+				y := y0 - (len(clues)-i)*size + size/2
+				fmt.Fprintf(bw, "<text x=\"%d\" y=\"%d\">%s</text>\n", x0+c*size+size/2, y, clue)
+			}
+		}
+		bw.WriteString("</g>\n")
+	}
+
+	if board != nil {
+		var marks strings.Builder
+		bw.WriteString("<g fill=\"black\">\n")
+		for r := range board {
+			for c, cell := range board[r] {
+				x, y := x0+c*size, y0+r*size
+				switch {
+				case cell == full:
+					fmt.Fprintf(bw, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/>\n", x, y, size, size)
+				case cell == marked && o.Marks:
+					d := size / 4
+					fmt.Fprintf(&marks, "M%d %dL%d %dM%d %dL%d %d",
+						x+d, y+d, x+size-d, y+size-d, x+size-d, y+d, x+d, y+size-d)
+				}
+			}
+		}
+		bw.WriteString("</g>\n")
+		if marks.Len() > 0 {
+			fmt.Fprintf(bw, "<path d=\"%s\" stroke=\"gray\" stroke-width=\"%d\"/>\n", marks.String(), max(1, size/10))
+		}
+	}
+
+	// thin lines for every cell, bold ones every 5 cells and around the grid
+	thin, bold := max(1, size/20), max(2, size/8)
+	for r := 0; r <= rows; r++ {
+		stroke := thin
+		if r%5 == 0 || r == rows {
+			stroke = bold
+		}
+		fmt.Fprintf(bw, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\" stroke-width=\"%d\" stroke-linecap=\"square\"/>\n",
+			x0, y0+r*size, x0+cols*size, y0+r*size, stroke)
+	}
+	for c := 0; c <= cols; c++ {
+		stroke := thin
+		if c%5 == 0 || c == cols {
+			stroke = bold
+		}
+		fmt.Fprintf(bw, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\" stroke-width=\"%d\" stroke-linecap=\"square\"/>\n",
+			x0+c*size, y0, x0+c*size, y0+rows*size, stroke)
+	}
+
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
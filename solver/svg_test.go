@@ -0,0 +1,85 @@
+package solver
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// svgElements counts the elements of an SVG document by name, failing if it's not
+// well-formed XML.
+func svgElements(t *testing.T, data []byte) map[string]int {
+	counts := make(map[string]int)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				t.Errorf("Invalid SVG: %v", err)
+				t.FailNow()
+			}
+			return counts
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	board := NewTreeSolver(puz).Solve()
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, puz, board, SVGOptions{CellSize: 30, Marks: true}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	counts := svgElements(t, buf.Bytes())
+
+	// the background and the 16 full cells
+	if counts["rect"] != 1+16 {
+		t.Errorf("Expected 17 rects, got %d", counts["rect"])
+		t.FailNow()
+	}
+	// 8 numbers in the row clues and 10 in the column clues
+	if counts["text"] != 8+10 {
+		t.Errorf("Expected 18 clues, got %d", counts["text"])
+		t.FailNow()
+	}
+	if counts["line"] != 6+6 || counts["path"] != 1 {
+		t.Errorf("Expected 12 grid lines and the marks, got %v", counts)
+		t.FailNow()
+	}
+	// 3 clue cells, the grid and half a cell of margin on each side
+	if !strings.Contains(buf.String(), `width="270" height="270"`) {
+		t.Errorf("Unexpected size in\n%s", buf.String()[:200])
+		t.FailNow()
+	}
+}
+
+func TestWriteSVGPuzzle(t *testing.T) {
+	puz := Puzzle{Name: "<blank & hidden>", Rows: [][]int{{1}, nil}, Cols: [][]int{{Unknown}, {0}}}
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, puz, nil, SVGOptions{FontFamily: "Helvetica"}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	counts := svgElements(t, buf.Bytes())
+
+	if counts["rect"] != 1 || counts["path"] != 0 || counts["text"] != 4 {
+		t.Errorf("Expected a blank grid with clues, got %v", counts)
+		t.FailNow()
+	}
+	for _, s := range []string{"&lt;blank &amp; hidden&gt;", `font-family="Helvetica"`, ">?</text>"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Missing %s in\n%s", s, buf.String())
+			t.FailNow()
+		}
+	}
+}
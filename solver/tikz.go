@@ -0,0 +1,170 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TikZOptions configures the pictures written by WriteTikZ and WriteTikZTrace, the zero
+// value draws 5mm cells with clues and no marks.
+type TikZOptions struct {
+	// CellSize is the side of a cell in centimeters
+	CellSize float64
+	// Marks draws a × in the marked cells
+	Marks bool
+	// NoClues leaves out the clues, drawing only the grid
+	NoClues bool
+}
+
+// WriteTikZ writes the puzzle as a LaTeX tikzpicture, with the row clues to the left of
+// the grid and the column clues above it. The full cells of the board are shaded, a nil
+// board draws the blank puzzle.
+//
+// The picture only needs \usepackage{tikz} in the preamble.
+func WriteTikZ(w io.Writer, p Puzzle, board Board, options TikZOptions) error {
+	bw := bufio.NewWriter(w)
+	writeTikZPicture(bw, p, board, nil, options)
+	return bw.Flush()
+}
+
+// WriteTikZTrace writes a tikzpicture for each step recorded by a Tracer, each one
+// showing the board after the step with the cells it deduced highlighted, under a
+// paragraph describing the step.
+func WriteTikZTrace(w io.Writer, p Puzzle, steps []TraceStep, options TikZOptions) error {
+	bw := bufio.NewWriter(w)
+	for i, step := range steps {
+		if i > 0 {
+			fmt.Fprintln(bw, `\medskip`)
+		}
+		fmt.Fprintf(bw, "\\noindent Step %d: %s\\par\n", i+1, step.Description)
+		writeTikZPicture(bw, p, step.Board, step.Cells, options)
+	}
+	return bw.Flush()
+}
+
+func writeTikZPicture(w io.Writer, p Puzzle, board Board, highlight [][2]int, options TikZOptions) {
+	size := options.CellSize
+	if size <= 0 {
+		size = 0.5
+	}
+	rows, cols := len(p.Rows), len(p.Cols)
+
+	// the y axis goes down, so that cells are at (column, row) as in the board
+	fmt.Fprintf(w, "%% %s\n", strings.Join(strings.Fields(p.Name), " "))
+	fmt.Fprintf(w, "\\begin{tikzpicture}[x=%gcm, y=-%gcm]\n", size, size)
+
+	for _, cell := range highlight {
+		fmt.Fprintf(w, "  \\fill[yellow!60] (%d,%d) rectangle ++(1,1);\n", cell[1], cell[0])
+	}
+	for r := range board {
+		for c, value := range board[r] {
+			switch {
+			case value == full && isHighlighted(highlight, r, c):
+				fmt.Fprintf(w, "  \\fill[black!75] (%g,%g) rectangle ++(0.8,0.8);\n", float64(c)+0.1, float64(r)+0.1)
+			case value == full:
+				fmt.Fprintf(w, "  \\fill (%d,%d) rectangle ++(1,1);\n", c, r)
+			case value == marked && (options.Marks || isHighlighted(highlight, r, c)):
+				fmt.Fprintf(w, "  \\draw[gray] (%g,%g) -- ++(0.5,0.5) ++(0,-0.5) -- ++(-0.5,0.5);\n", float64(c)+0.25, float64(r)+0.25)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "  \\draw[gray!50, very thin] (0,0) grid (%d,%d);\n", cols, rows)
+	for r := 0; r <= rows; r += 5 {
+		fmt.Fprintf(w, "  \\draw[thick] (0,%d) -- (%d,%d);\n", r, cols, r)
+	}
+	for c := 0; c <= cols; c += 5 {
+		fmt.Fprintf(w, "  \\draw[thick] (%d,0) -- (%d,%d);\n", c, c, rows)
+	}
+	fmt.Fprintf(w, "  \\draw[thick] (0,0) rectangle (%d,%d);\n", cols, rows)
+
+	if !options.NoClues {
+		fmt.Fprintln(w, `  \begin{scope}[every node/.style={font=\footnotesize}]`)
+		for r, constraints := range p.Rows {
+			clues := clueStrings(constraints)
+			for i, clue := range clues {
+				fmt.Fprintf(w, "    \\node at (%g,%g) {%s};\n", float64(i-len(clues))+0.5, float64(r)+0.5, clue)
+			}
+		}
+		for c, constraints := range p.Cols {
+			clues := clueStrings(constraints)
+			for i, clue := range clues {
+				fmt.Fprintf(w, "    \\node at (%g,%g) {%s};\n", float64(c)+0.5, float64(i-len(clues))+0.5, clue)
+			}
+		}
+		fmt.Fprintln(w, `  \end{scope}`)
+	}
+	fmt.Fprintln(w, `\end{tikzpicture}`)
+}
+
+func isHighlighted(highlight [][2]int, r int, c int) bool {
+	for _, cell := range highlight {
+		if cell[0] == r && cell[1] == c {
+			return true
+		}
+	}
+	return false
+}
+
+// A TraceStep is a step of the solving recorded by a Tracer.
+type TraceStep struct {
+	// Description tells what the solver did, e.g. "row 3 solved, 2 cells deduced", with
+	// rows and columns numbered from 1
+	Description string
+	// Cells are the row and column of the cells deduced or guessed in the step
+	Cells [][2]int
+	// Board is the board after the step
+	Board Board
+}
+
+// A Tracer is an Observer recording the steps of the solving that changed the board:
+// the lines solved that deduced some cells, the guesses and the backtracks.
+type Tracer struct {
+	BaseObserver
+	Steps   []TraceStep
+	changed []int
+}
+
+// NewTracer creates a Tracer with no steps.
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// CellsChanged implements Observer, the step is recorded by the following LineSolved.
+func (t *Tracer) CellsChanged(lt LineType, index int, cells []int, board Board) {
+	t.changed = cells
+}
+
+// LineSolved implements Observer
+func (t *Tracer) LineSolved(lt LineType, index int, board Board) {
+	if len(t.changed) == 0 {
+		return
+	}
+	cells := make([][2]int, len(t.changed))
+	for i, cell := range t.changed {
+		cells[i] = [2]int{index, cell}
+		if lt == column {
+			cells[i] = [2]int{cell, index}
+		}
+	}
+	t.record(fmt.Sprintf("%v %d solved, %d cells deduced", lt, index+1, len(cells)), cells, board)
+	t.changed = nil
+}
+
+// Guess implements Observer
+func (t *Tracer) Guess(r int, c int, value Cell, depth int, board Board) {
+	description := fmt.Sprintf("guessed %v at row %d, column %d", value, r+1, c+1)
+	t.record(description, [][2]int{{r, c}}, board)
+}
+
+// Backtrack implements Observer
+func (t *Tracer) Backtrack(r int, c int, depth int, board Board) {
+	description := fmt.Sprintf("backtracked from row %d, column %d", r+1, c+1)
+	t.record(description, nil, board)
+}
+
+func (t *Tracer) record(description string, cells [][2]int, board Board) {
+	t.Steps = append(t.Steps, TraceStep{Description: description, Cells: cells, Board: board})
+}
@@ -0,0 +1,91 @@
+package solver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteTikZ(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	board := NewTreeSolver(puz).Solve()
+
+	var buf bytes.Buffer
+	if err := WriteTikZ(&buf, puz, board, TikZOptions{CellSize: 0.4}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	tex := buf.String()
+
+	if !strings.Contains(tex, `\begin{tikzpicture}[x=0.4cm, y=-0.4cm]`) || !strings.HasSuffix(tex, "\\end{tikzpicture}\n") {
+		t.Errorf("Missing the tikzpicture environment in\n%s", tex)
+		t.FailNow()
+	}
+	// the 16 full cells and 18 numbers in the clues
+	if n := strings.Count(tex, `\fill (`); n != 16 {
+		t.Errorf("Expected 16 full cells, got %d", n)
+		t.FailNow()
+	}
+	if n := strings.Count(tex, `\node`); n != 18 {
+		t.Errorf("Expected 18 clues, got %d", n)
+		t.FailNow()
+	}
+	if strings.Count(tex, "{") != strings.Count(tex, "}") {
+		t.Errorf("Unbalanced braces in\n%s", tex)
+		t.FailNow()
+	}
+}
+
+func TestTracer(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+	puz, _ := inputFile.GetByName("smiley")
+	tracer := NewTracer()
+	board := NewTreeSolver(puz, WithObserver(tracer)).Solve()
+
+	if len(tracer.Steps) == 0 {
+		t.Errorf("No steps recorded")
+		t.FailNow()
+	}
+
+	// every cell is deduced exactly once, ending with the solution
+	deduced := make(map[[2]int]int)
+	for _, step := range tracer.Steps {
+		for _, cell := range step.Cells {
+			deduced[cell]++
+		}
+	}
+	if len(deduced) != 25 {
+		t.Errorf("Expected all 25 cells to be deduced, got %d", len(deduced))
+		t.FailNow()
+	}
+	for cell, n := range deduced {
+		if n != 1 {
+			t.Errorf("Cell %v deduced %d times", cell, n)
+			t.FailNow()
+		}
+	}
+	if last := tracer.Steps[len(tracer.Steps)-1].Board; last.String() != board.String() {
+		t.Errorf("The last step doesn't end with the solution")
+		t.FailNow()
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTikZTrace(&buf, puz, tracer.Steps, TikZOptions{NoClues: true}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	tex := buf.String()
+	if n := strings.Count(tex, `\begin{tikzpicture}`); n != len(tracer.Steps) {
+		t.Errorf("Expected %d pictures, got %d", len(tracer.Steps), n)
+		t.FailNow()
+	}
+	if n := strings.Count(tex, `[yellow!60]`); n != 25 {
+		t.Errorf("Expected 25 highlighted cells, got %d", n)
+		t.FailNow()
+	}
+	if !strings.Contains(tex, `\noindent Step 1: `) || strings.Contains(tex, `\node`) {
+		t.Errorf("Unexpected trace\n%s", tex)
+		t.FailNow()
+	}
+}
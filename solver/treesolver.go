@@ -1,8 +1,9 @@
 package solver
 
 import (
-	"reflect"
-	"sort"
+	"container/heap"
+	"context"
+	"time"
 )
 
 // LineType identifies a line (slice of Cell) as either a row or column
@@ -15,92 +16,242 @@ const (
 
 // treeSolverJob represents lines of the board (either rows or columns) that
 // have yet to be solved.
-// The score is used as priority for job execution.
+// The score is used as priority for job execution, it grows with the number of
+// cells of the line that changed since the job was queued.
 type treeSolverJob struct {
 	ltype       LineType
 	index       int
-	line        []Cell
 	constraints []int
 	score       int
+	heapIndex   int
 }
 
-type treeSolverJobs []treeSolverJob
+// treeSolverJobs is a priority queue of jobs, implementing heap.Interface so that
+// the job with the highest score is solved first.
+type treeSolverJobs []*treeSolverJob
 
-func (slice treeSolverJobs) Len() int {
-	return len(slice)
+func (jobs treeSolverJobs) Len() int {
+	return len(jobs)
 }
 
-func (slice treeSolverJobs) Less(i, j int) bool {
-	return slice[i].score < slice[j].score
+func (jobs treeSolverJobs) Less(i, j int) bool {
+	return jobs[i].score > jobs[j].score
 }
 
-func (slice treeSolverJobs) Swap(i, j int) {
-	slice[i], slice[j] = slice[j], slice[i]
+func (jobs treeSolverJobs) Swap(i, j int) {
+	jobs[i], jobs[j] = jobs[j], jobs[i]
+	jobs[i].heapIndex = i
+	jobs[j].heapIndex = j
+}
+
+func (jobs *treeSolverJobs) Push(x interface{}) {
+	job := x.(*treeSolverJob)
+	job.heapIndex = len(*jobs)
+	*jobs = append(*jobs, job)
+}
+
+func (jobs *treeSolverJobs) Pop() interface{} {
+	old := *jobs
+	job := old[len(old)-1]
+	old[len(old)-1] = nil
+	*jobs = old[:len(old)-1]
+	return job
 }
 
 // A TreeSolver solves a nonogram by trying to solve lines iteratively until the puzzle is completed
 //
-// it keeps track of lines that need to be solved yet in a slice of jobs, whenever one of the lines
-// is modified the other affected lines are reactivated for a new pass of the line solver, this until
-// no more jobs are available.
+// it keeps track of lines that need to be solved yet in a priority queue of jobs, whenever one of the
+// lines is modified the other affected lines are reactivated (or moved up in the queue if they're
+// already waiting) for a new pass of the line solver, this until no more jobs are available.
 //
 // if the puzzle can't be solved by just the line solver, it then picks a cell and fills it with a
 // value (either full or marked), puts the two boards in a binary tree and resumes solving with the
 // line solver using a depth-first strategy
+//
+// The board is kept packed in a bitBoard and lines are solved with the bitwise line solver,
+// it is converted to a Board only when returned.
 type TreeSolver struct {
 	puzzle     Puzzle
-	board      Board
+	board      bitBoard
 	jobs       treeSolverJobs
-	activeJobs int
+	queued     [2][]*treeSolverJob
+	cache      *LineCache
+	lineSolver func([]int, bitLine) (bitLine, bool)
+	workers    int
+	stats      Stats
+	depth      int
+	observers  []Observer
+	// done stops the search when closed, as the Done channel of a context
+	done       <-chan struct{}
 	GuessCount int
 }
 
+// An Option configures a TreeSolver when it is created.
+type Option func(*TreeSolver)
+
+// WithLineCache makes the solver look up line results in the given cache before solving
+// a line. The same cache can be shared by solvers running concurrently.
+func WithLineCache(cache *LineCache) Option {
+	return func(t *TreeSolver) {
+		t.cache = cache
+	}
+}
+
+// WithAutomatonLineSolver makes the solver use the automaton line solver of
+// SolveLineAutomaton in place of the bitwise one.
+func WithAutomatonLineSolver() Option {
+	return func(t *TreeSolver) {
+		t.lineSolver = solveAutomatonBitLine
+	}
+}
+
 // NewTreeSolver returns a newly created Solver for the given puzzle
-func NewTreeSolver(p Puzzle) *TreeSolver {
-	t := TreeSolver{puzzle: p}
-	t.board = NewBoard(len(p.Rows), len(p.Cols))
+func NewTreeSolver(p Puzzle, options ...Option) *TreeSolver {
+	t := TreeSolver{puzzle: p, lineSolver: solveBitLine}
+	for _, option := range options {
+		option(&t)
+	}
+	t.board = newBitBoard(len(p.Rows), len(p.Cols))
+	t.queued[row] = make([]*treeSolverJob, len(p.Rows))
+	t.queued[column] = make([]*treeSolverJob, len(p.Cols))
 	t.initJobs()
-	t.activeJobs = len(p.Rows) + len(p.Cols)
 	return &t
 }
 
 // Solve implements the Solver interface, returning a fully solved Board
+//
+// If the puzzle has no solution the returned Board is the one reached by the
+// line solver before finding a contradiction.
 func (t *TreeSolver) Solve() Board {
-	_, _ = t.logicSolve()
-	return t.board //TODO: not implemented yet
+	t.solve()
+	return t.board.toBoard()
 }
 
-func (t *TreeSolver) getLine(lt LineType, index int) []Cell {
-	if lt == row {
-		return t.board[index]
-	}
-	result := make([]Cell, len(t.puzzle.Cols))
-	for i := 0; i < len(t.puzzle.Rows); i++ {
-		result[i] = t.board[i][index]
-	}
-	return result
+// SolveContext looks for a solution until one is found or ctx is cancelled, ok is false
+// if the puzzle has no solution or the search was stopped, in which case the returned
+// Board is the one reached when the search ended.
+func (t *TreeSolver) SolveContext(ctx context.Context) (board Board, ok bool) {
+	t.done = ctx.Done()
+	ok = t.solve() && ctx.Err() == nil
+	return t.board.toBoard(), ok
 }
 
-func (t *TreeSolver) emptyCells() int {
-	count := 0
-	for _, line := range t.board {
-		for _, cell := range line {
-			if cell == empty {
-				count++
+// Solutions returns a channel on which every solution of the puzzle is sent, one by one,
+// in the order they are found by the search. The channel is closed once the whole search
+// tree has been explored or as soon as ctx is cancelled, so callers that only need the
+// first few solutions should cancel ctx when done reading.
+//
+// The search runs in its own goroutine, the TreeSolver must not be used until the channel
+// is closed.
+func (t *TreeSolver) Solutions(ctx context.Context) <-chan Board {
+	solutions := make(chan Board)
+	t.done = ctx.Done()
+
+	go func() {
+		defer close(solutions)
+		defer t.timeSearch()()
+		t.search(func(b Board) bool {
+			select {
+			case solutions <- b:
+				return true
+			case <-ctx.Done():
+				return false
 			}
+		})
+	}()
+	return solutions
+}
+
+// solve searches for the first solution of the puzzle, leaving it in t.board.
+// It returns false if no solution exists.
+func (t *TreeSolver) solve() (solved bool) {
+	defer t.timeSearch()()
+	t.search(func(b Board) bool {
+		solved = true
+		return false
+	})
+	return
+}
+
+// search explores the puzzle depth-first, guessing the value of a cell whenever
+// the line solver can't make any progress, and calls found with every solution.
+// The search stops as soon as found returns false, leaving the last solution in
+// t.board, in which case search returns false as well.
+func (t *TreeSolver) search(found func(Board) bool) bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+	}
+
+	emptyCells, ok := t.logicSolve()
+	if !ok {
+		t.stats.Contradictions++
+		return true
+	}
+	if emptyCells == 0 {
+		board := t.board.toBoard()
+		t.notifySolution(board)
+		return found(board)
+	}
+
+	r, c := t.pickCell()
+	saved := t.board.clone()
+
+	t.depth++
+	defer func() { t.depth-- }()
+	if t.depth > t.stats.MaxDepth {
+		t.stats.MaxDepth = t.depth
+	}
+
+	for i, guess := range []Cell{full, marked} {
+		if i > 0 {
+			// the previous value failed, undo it to try this one
+			t.stats.Backtracks++
+			t.board = saved.clone()
+			t.notifyBacktrack(r, c)
+		}
+		t.GuessCount++
+		t.board.set(r, c, guess)
+		t.clearJobs()
+		t.addJob(row, r, 1)
+		t.addJob(column, c, 1)
+		t.notifyGuess(r, c, guess)
+
+		if !t.search(found) {
+			return false
 		}
 	}
-	return count
+	return true
 }
 
-func (t *TreeSolver) setLine(lt LineType, index int, line []Cell) {
-	if lt == row {
-		t.board[index] = line
-	} else {
-		for i := 0; i < len(t.puzzle.Rows); i++ {
-			t.board[i][index] = line[i]
+// timeSearch starts timing a search, the returned function adds the elapsed time
+// to the stats of the solver.
+func (t *TreeSolver) timeSearch() func() {
+	start := time.Now()
+	return func() {
+		t.stats.TotalTime += time.Since(start)
+	}
+}
+
+// pickCell returns the position of the first cell that has not been solved yet.
+func (t *TreeSolver) pickCell() (r int, c int) {
+	unsolved := newBitset(len(t.puzzle.Cols))
+	for r, line := range t.board.rows {
+		unsolved.and(line.full, line.empty)
+		if c = unsolved.next(0); c >= 0 {
+			return r, c
 		}
 	}
+	return -1, -1
+}
+
+func (t *TreeSolver) getLine(lt LineType, index int) []Cell {
+	return t.board.line(lt, index).cells()
+}
+
+func (t *TreeSolver) emptyCells() int {
+	return t.board.emptyCells()
 }
 
 func (t *TreeSolver) score(lt LineType, index int) int {
@@ -109,15 +260,19 @@ func (t *TreeSolver) score(lt LineType, index int) int {
 
 	if lt == row {
 		constraints = t.puzzle.Rows[index]
-		l = len(t.puzzle.Rows)
+		l = len(t.puzzle.Cols)
 	} else {
 		constraints = t.puzzle.Cols[index]
-		l = len(t.puzzle.Cols)
+		l = len(t.puzzle.Rows)
 	}
 
 	b, n := 0, len(constraints)
 
 	for _, c := range constraints {
+		if c == Unknown {
+			// an unknown block is at least one cell long
+			c = 1
+		}
 		b += c
 	}
 
@@ -128,50 +283,81 @@ func (t *TreeSolver) score(lt LineType, index int) int {
 }
 
 func (t *TreeSolver) initJobs() {
-	t.jobs = make([]treeSolverJob, 0)
+	t.clearJobs()
 
 	for i := 0; i < len(t.puzzle.Rows); i++ {
-		t.jobs = append(t.jobs, treeSolverJob{row, i, t.getLine(row, i), t.puzzle.Rows[i], t.score(row, i)})
+		t.addJob(row, i, 0)
 	}
 
 	for i := 0; i < len(t.puzzle.Cols); i++ {
-		t.jobs = append(t.jobs, treeSolverJob{column, i, t.getLine(column, i), t.puzzle.Cols[i], t.score(column, i)})
+		t.addJob(column, i, 0)
 	}
 }
 
-func (t *TreeSolver) updateJobs(oldJob treeSolverJob, newLine []Cell) {
-	count := 0
-	for i, v := range newLine {
-		if v != oldJob.line[i] {
-			found := false
-			count++
+// clearJobs empties the queue, e.g. after a contradiction.
+func (t *TreeSolver) clearJobs() {
+	for _, job := range t.jobs {
+		t.queued[job.ltype][job.index] = nil
+	}
+	t.jobs = t.jobs[:0]
+}
 
-			for _, job := range t.jobs {
-				if job.ltype != oldJob.ltype && job.index == i {
-					// update score for all jobs of columns if oldjob is a row, rows otherwise
-					job.score = t.score(job.ltype, job.index)
-					found = true
-				}
-			}
+// addJob queues a line for solving, if the line is already waiting in the queue its score
+// is raised by the number of changed cells instead.
+func (t *TreeSolver) addJob(lt LineType, index int, changed int) {
+	if job := t.queued[lt][index]; job != nil {
+		job.score += changed
+		heap.Fix(&t.jobs, job.heapIndex)
+		return
+	}
 
-			if !found {
-				var lt LineType
-				var constraints []int
-				if oldJob.ltype == row {
-					lt = column
-					constraints = t.puzzle.Cols[i]
-				} else {
-					lt = row
-					constraints = t.puzzle.Rows[i]
-				}
-
-				t.jobs = append(t.jobs, treeSolverJob{lt, i, t.getLine(lt, i), constraints, t.score(lt, i)})
-			}
-		}
+	constraints := t.puzzle.Rows
+	if lt == column {
+		constraints = t.puzzle.Cols
 	}
+	job := &treeSolverJob{ltype: lt, index: index, constraints: constraints[index], score: t.score(lt, index) + changed}
+	t.queued[lt][index] = job
+	heap.Push(&t.jobs, job)
+}
+
+// popJob removes the job with the highest score from the queue.
+func (t *TreeSolver) popJob() *treeSolverJob {
+	job := heap.Pop(&t.jobs).(*treeSolverJob)
+	t.queued[job.ltype][job.index] = nil
+	return job
+}
+
+// updateJobs queues the lines crossing the changed cells of a line that was just solved.
+func (t *TreeSolver) updateJobs(solved *treeSolverJob, changed bitset) {
+	crossing := column
+	if solved.ltype == column {
+		crossing = row
+	}
+
+	for i := changed.next(0); i >= 0; i = changed.next(i + 1) {
+		t.addJob(crossing, i, 1)
+	}
+}
+
+// solveLine runs the line solver, going through the cache if the solver has one,
+// hit is true if the result was found in the cache.
+func (t *TreeSolver) solveLine(constraints []int, line bitLine) (result bitLine, ok bool, hit bool) {
+	if t.cache != nil {
+		return t.cache.solve(constraints, line, t.lineSolver)
+	}
+	result, ok = t.lineSolver(constraints, line)
+	return
 }
 
 func (t *TreeSolver) logicSolve() (emptyCells int, ok bool) {
+	start := time.Now()
+	defer func() {
+		t.stats.LogicTime += time.Since(start)
+	}()
+
+	if t.workers > 1 {
+		return t.parallelLogicSolve()
+	}
 	ok = true
 
 	if len(t.jobs) == 0 {
@@ -179,24 +365,27 @@ func (t *TreeSolver) logicSolve() (emptyCells int, ok bool) {
 	}
 
 	for len(t.jobs) > 0 {
-		// pop the last job from the slice
-		var job treeSolverJob
-		job, t.jobs = t.jobs[len(t.jobs)-1], t.jobs[:len(t.jobs)-1]
+		job := t.popJob()
+		line := t.board.line(job.ltype, job.index)
 
-		newLine, success := intersect(job.constraints, job.line)
+		newLine, success, hit := t.solveLine(job.constraints, line)
 
 		if !success {
 			//contradiction, stops solving
+			t.stats.lineSolved(nil, hit, t.cache != nil)
 			ok = false
 			return
 		}
 
-		if !reflect.DeepEqual(job.line, newLine) {
+		var changed bitset
+		if !line.equal(newLine) {
 			// update the line and jobs
-			t.setLine(job.ltype, job.index, newLine)
-			t.updateJobs(job, newLine)
-			sort.Sort(t.jobs)
+			changed = line.changes(newLine)
+			t.board.setLine(job.ltype, job.index, newLine, changed)
+			t.updateJobs(job, changed)
 		}
+		t.stats.lineSolved(changed, hit, t.cache != nil)
+		t.notifyLine(job.ltype, job.index, changed)
 	}
 	emptyCells = t.emptyCells()
 	return
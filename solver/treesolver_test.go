@@ -1,6 +1,10 @@
 package solver
 
-import "testing"
+import (
+	"context"
+	"reflect"
+	"testing"
+)
 
 func TestSolve(t *testing.T) {
 	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
@@ -27,3 +31,99 @@ func TestReadPuzzleFile(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestSolveAll(t *testing.T) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		s := NewTreeSolver(puz)
+		board := s.Solve()
+
+		if s.emptyCells() != 0 {
+			t.Errorf("Puzzle %s was not solved", puz.Name)
+			t.FailNow()
+		}
+
+		for i, constraints := range puz.Rows {
+			if !hasUnknown(constraints) && !reflect.DeepEqual(lineClues(board[i]), constraints) {
+				t.Errorf("Puzzle %s: row %d doesn't match %v", puz.Name, i, constraints)
+				t.FailNow()
+			}
+		}
+		for i, constraints := range puz.Cols {
+			if !hasUnknown(constraints) && !reflect.DeepEqual(lineClues(s.getLine(column, i)), constraints) {
+				t.Errorf("Puzzle %s: column %d doesn't match %v", puz.Name, i, constraints)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestSolutions(t *testing.T) {
+	// a 2x2 puzzle with two solutions: the two diagonals
+	puz := Puzzle{Name: "diagonals", Rows: [][]int{{1}, {1}}, Cols: [][]int{{1}, {1}}}
+
+	count := 0
+	for board := range NewTreeSolver(puz).Solutions(context.Background()) {
+		if board[0][0] != board[1][1] || board[0][1] != board[1][0] || board[0][0] == board[0][1] {
+			t.Errorf("Invalid solution\n%v", board)
+			t.FailNow()
+		}
+		count++
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 solutions, got %d", count)
+		t.FailNow()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	solutions := NewTreeSolver(puz).Solutions(ctx)
+	<-solutions
+	cancel()
+
+	// the channel must be closed once cancelled, at most one more solution can be received
+	for i := 0; i < 2; i++ {
+		if _, ok := <-solutions; !ok {
+			return
+		}
+	}
+	t.Errorf("Expected the channel to be closed after cancel")
+}
+
+func BenchmarkSolve(b *testing.B) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		b.Run(puz.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewTreeSolver(puz).Solve()
+			}
+		})
+	}
+}
+
+func BenchmarkSolveWithCache(b *testing.B) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		b.Run(puz.Name, func(b *testing.B) {
+			cache := NewLineCache(1 << 16)
+			for i := 0; i < b.N; i++ {
+				NewTreeSolver(puz, WithLineCache(cache)).Solve()
+			}
+		})
+	}
+}
+
+func BenchmarkSolveParallel(b *testing.B) {
+	inputFile, _ := ReadJSONPuzzleFile("../puzzles/nonogram.json")
+
+	for _, puz := range inputFile.Puzzles {
+		b.Run(puz.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewTreeSolver(puz, WithWorkers(0)).Solve()
+			}
+		})
+	}
+}
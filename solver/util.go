@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
 )
 
 // JSONObject is the base struct for decoding JSON files containing one or more nonogram puzzles
@@ -15,12 +17,84 @@ type JSONObject struct {
 
 // Puzzle has a name and two 2-dimensional slices of integers representing
 // the constraints of the puzzle
+//
+// Block lengths that are not known are stored as Unknown, while a line
+// without any constraint (nil) is fully unknown.
 type Puzzle struct {
 	Name string
 	Rows [][]int
 	Cols [][]int
 }
 
+// UnmarshalJSON decodes a puzzle, accepting null, "?" or -1 in place of an unknown
+// block length and null or [] in place of a fully unknown line, while [0] is a line
+// without full cells. Any other clue must be a non-negative integer.
+//
+//	"rows" : [[3, null, 2], null, [1, "?"], [-1]]
+func (p *Puzzle) UnmarshalJSON(data []byte) (err error) {
+	var raw struct {
+		Name string
+		Rows []json.RawMessage
+		Cols []json.RawMessage
+	}
+
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return
+	}
+
+	p.Name = raw.Name
+	if p.Rows, err = decodeClues(raw.Rows, row); err != nil {
+		return
+	}
+	p.Cols, err = decodeClues(raw.Cols, column)
+	return
+}
+
+// decodeClues decodes the clues of the rows or columns, errors tell the line by its
+// type and number, counting from 1.
+func decodeClues(lines []json.RawMessage, lt LineType) (clues [][]int, err error) {
+	clues = make([][]int, len(lines))
+
+	for i, line := range lines {
+		var blocks []interface{}
+		if err = json.Unmarshal(line, &blocks); err != nil {
+			return
+		}
+		if blocks == nil {
+			// fully unknown line
+			continue
+		}
+
+		clues[i] = make([]int, len(blocks))
+		for j, block := range blocks {
+			switch v := block.(type) {
+			case nil:
+				clues[i][j] = Unknown
+			case float64:
+				switch {
+				case v == Unknown:
+					clues[i][j] = Unknown
+				case v < 0 || v > math.MaxInt32 || v != math.Trunc(v):
+					err = fmt.Errorf("Invalid clue %s in %v %d", strconv.FormatFloat(v, 'f', -1, 64), lt, i+1)
+					return
+				default:
+					clues[i][j] = int(v)
+				}
+			case string:
+				if v != "?" {
+					err = fmt.Errorf("Invalid clue %q in %v %d", v, lt, i+1)
+					return
+				}
+				clues[i][j] = Unknown
+			default:
+				err = fmt.Errorf("Invalid clue %v in %v %d", v, lt, i+1)
+				return
+			}
+		}
+	}
+	return
+}
+
 // ReadJSONPuzzleFile reads a json file and tries to parse it, returning a JSONObject with the
 // file structure.
 func ReadJSONPuzzleFile(name string) (puzzles JSONObject, err error) {
@@ -33,7 +107,7 @@ func ReadJSONPuzzleFile(name string) (puzzles JSONObject, err error) {
 	reader := bufio.NewReader(f)
 	dec := json.NewDecoder(reader)
 	// decodes the file in the puzzles struct
-	dec.Decode(&puzzles)
+	err = dec.Decode(&puzzles)
 
 	return
 }